@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"web/internal/auth"
+	"web/internal/config"
+	"web/internal/server/context/request"
+	"web/internal/server/server"
+	"web/internal/server/server/handlers"
+	"web/internal/storage"
+)
+
+// command subcommand of the server binary, run gets args after name of the command
+type command struct {
+	usage string
+	run   func(cfg *config.Config, log *slog.Logger, args []string) error
+}
+
+// commands subcommands of the server binary, the server is started without subcommand
+var commands = map[string]command{
+	"serve":   {"serve", serveCommand},
+	"migrate": {"migrate", migrateCommand},
+	"seed":    {"seed [-user demo] [-password password]", seedCommand},
+	"backup":  {"backup file", backupCommand},
+	"restore": {"restore file", restoreCommand},
+	"check":   {"check", checkCommand},
+	"user":    {"user create -name name [-password password] [-role role]", userCommand},
+	"apikey":  {"apikey create -user name -name name [-role role]", apiKeyCommand},
+}
+
+// serveCommand starts http server
+func serveCommand(cfg *config.Config, log *slog.Logger, args []string) error {
+	if err := parseFlags(flag.NewFlagSet("serve", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	// Create Sql connection
+	SqlDataBase := SqlConnect(cfg, log)
+	// Open blob store of attachments
+	blobStore := BlobConnect(cfg, log)
+
+	// Create new server
+	httpServer := server.NewServer(cfg, &SqlDataBase, blobStore, log)
+	// Init handlers
+	allHandlers := handlers.NewHandlers(httpServer)
+	httpServer.InitHandlers(allHandlers)
+	// init middlewares
+	initMiddlewares(httpServer)
+	// Init routes
+	initRoutes(httpServer)
+	// Purge old trash in background
+	httpServer.Go(httpServer.PurgeTrash)
+
+	// Stop server on Ctrl-C or SIGTERM, the second signal kills server without waiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	// Start server
+	return httpServer.Start(ctx)
+}
+
+// migrateCommand applies new migrations to the database, database is migrated on connect
+func migrateCommand(cfg *config.Config, log *slog.Logger, args []string) error {
+	if err := parseFlags(flag.NewFlagSet("migrate", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	db := SqlConnect(cfg, log)
+	if err := db.Close(); err != nil {
+		return err
+	}
+	log.Info("Database schema is up to date")
+	return nil
+}
+
+// seedCommand creates demo user with project, tags and tasks. Existing user gets demo data too,
+// unless he already has demo project
+func seedCommand(cfg *config.Config, log *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	name := flags.String("user", "demo", "name of the demo user")
+	password := flags.String("password", "demo-password", "password of the demo user, used if user is created")
+	if err := parseFlags(flags, args, 0); err != nil {
+		return err
+	}
+
+	db := SqlConnect(cfg, log)
+	defer db.Close()
+	user, err := findOrCreateUser(db, *name, *password)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Truncate(24 * time.Hour)
+	tasks := []storage.TaskCreate{
+		{Text: "Pay electricity bill", Tags: []string{"home"}, Priority: 1},
+		{Text: "Buy groceries", Tags: []string{"home", "errands"}, Priority: 2},
+		{Text: "Pick up parcel", Tags: []string{"errands"}, Priority: 3},
+		{Text: "Prepare release notes", Tags: []string{"work"}, Priority: 1},
+		{Text: "Review pull requests", Tags: []string{"work"}, Priority: 0},
+		{Text: "Plan next sprint", Tags: []string{"work", "planning"}, Priority: 2},
+	}
+	err = db.Transaction(func(tx storage.Storage) error {
+		project, err := tx.CreateProject(user.Id, "Demo")
+		if err != nil {
+			return err
+		}
+		for _, tag := range []string{"home", "errands"} {
+			if err = tx.CreateTag(user.Id, 0, tag); err != nil && !isConflict(err) {
+				return err
+			}
+		}
+		for _, tag := range []string{"work", "planning"} {
+			if err = tx.CreateTag(user.Id, project.Id, tag); err != nil {
+				return err
+			}
+		}
+		for i, task := range tasks {
+			// one overdue task, others are due in the next days
+			due := now.AddDate(0, 0, i-1)
+			task.Due = &due
+			if task.Tags[0] == "work" {
+				task.ProjectId = project.Id
+			}
+			if _, err = tx.CreateTask(user.Id, &task); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info("Seeded demo data", slog.String("user", user.Name), slog.Int("tasks", len(tasks)))
+	return nil
+}
+
+// findOrCreateUser returns user by name, user with the password is created if not found
+func findOrCreateUser(db storage.Storage, name, password string) (*storage.User, error) {
+	user, _, err := db.GetUserCredentials(name)
+	var errSql storage.SqlError
+	if err == nil || !errors.As(err, &errSql) || errSql.GetCode() != http.StatusNotFound {
+		return user, err
+	}
+
+	userRequest := request.UserRequest{Name: name, Password: password}
+	if err = userRequest.ValidateRequest(); err != nil {
+		return nil, err
+	}
+	passwordHash, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	return db.CreateUser(name, passwordHash)
+}
+
+// backupCommand writes snapshot of the database to file, file is replaced only by complete snapshot
+func backupCommand(cfg *config.Config, log *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 1); err != nil {
+		return err
+	}
+	path := flags.Arg(0)
+
+	db := SqlConnect(cfg, log)
+	defer db.Close()
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err = db.Backup(file); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	if err = os.Rename(file.Name(), path); err != nil {
+		return err
+	}
+
+	log.Info("Database is saved", slog.String("file", path))
+	return nil
+}
+
+// restoreCommand replaces all data of the database with snapshot from file
+func restoreCommand(cfg *config.Config, log *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	if err := parseFlags(flags, args, 1); err != nil {
+		return err
+	}
+	path := flags.Arg(0)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	db := SqlConnect(cfg, log)
+	defer db.Close()
+	if err = db.Restore(file); err != nil {
+		return err
+	}
+
+	log.Info("Database is restored", slog.String("file", path))
+	return nil
+}
+
+// checkCommand reports tasks whose tags column differs from task_tags, error if any task differs
+func checkCommand(cfg *config.Config, log *slog.Logger, args []string) error {
+	if err := parseFlags(flag.NewFlagSet("check", flag.ContinueOnError), args, 0); err != nil {
+		return err
+	}
+
+	db := SqlConnect(cfg, log)
+	defer db.Close()
+	mismatches, err := db.CheckTaskTags()
+	if err != nil {
+		return err
+	}
+	for _, mismatch := range mismatches {
+		if mismatch.Missing {
+			log.Warn("Tags are linked to missing task",
+				slog.Int("task", mismatch.TaskId), slog.String("links", strings.Join(mismatch.Links, ", ")))
+			continue
+		}
+		log.Warn("Task tags differ from task_tags", slog.Int("task", mismatch.TaskId),
+			slog.String("tags", strings.Join(mismatch.Tags, ", ")), slog.String("links", strings.Join(mismatch.Links, ", ")))
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d task(s) have inconsistent tags", len(mismatches))
+	}
+
+	log.Info("Task tags are consistent")
+	return nil
+}
+
+// userCommand creates user, password is read from stdin if flag is not set
+func userCommand(cfg *config.Config, log *slog.Logger, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return &usageError{"expect 'create'"}
+	}
+	flags := flag.NewFlagSet("user create", flag.ContinueOnError)
+	name := flags.String("name", "", "name of the user")
+	password := flags.String("password", "", "password of the user, read from stdin if not set")
+	role := flags.String("role", "", "role of the user: admin, member or read-only, default role of new user")
+	if err := parseFlags(flags, args[1:], 0); err != nil {
+		return err
+	}
+	if *role != "" && !storage.ValidRole(*role) {
+		return &usageError{fmt.Sprintf("unknown role '%s'", *role)}
+	}
+	if *password == "" {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return fmt.Errorf("can't read password from stdin: %w", err)
+		}
+		*password = strings.TrimRight(line, "\r\n")
+	}
+
+	userRequest := request.UserRequest{Name: *name, Password: *password}
+	if err := userRequest.ValidateRequest(); err != nil {
+		return err
+	}
+	passwordHash, err := auth.HashPassword(*password)
+	if err != nil {
+		return err
+	}
+
+	db := SqlConnect(cfg, log)
+	defer db.Close()
+	user, err := db.CreateUser(*name, passwordHash)
+	if err != nil {
+		return err
+	}
+	if *role != "" && *role != user.Role {
+		if user, err = db.SetUserRole(user.Name, *role); err != nil {
+			return err
+		}
+	}
+
+	log.Info("User is created", slog.String("name", user.Name), slog.String("role", user.Role))
+	return nil
+}
+
+// apiKeyCommand creates api key of the user and prints it, key can't be shown again
+func apiKeyCommand(cfg *config.Config, log *slog.Logger, args []string) error {
+	if len(args) == 0 || args[0] != "create" {
+		return &usageError{"expect 'create'"}
+	}
+	flags := flag.NewFlagSet("apikey create", flag.ContinueOnError)
+	userName := flags.String("user", "", "name of the key owner")
+	keyRequest := request.ApiKeyRequest{}
+	flags.StringVar(&keyRequest.Name, "name", "", "name of the key")
+	flags.StringVar(&keyRequest.Role, "role", "", "role of the key not higher than role of the user, default role of the user")
+	if err := parseFlags(flags, args[1:], 0); err != nil {
+		return err
+	}
+	if err := keyRequest.ValidateRequest(); err != nil {
+		return err
+	}
+
+	db := SqlConnect(cfg, log)
+	defer db.Close()
+	user, _, err := db.GetUserCredentials(*userName)
+	if err != nil {
+		return err
+	}
+	role := keyRequest.Role
+	if role == "" {
+		role = user.Role
+	}
+	if storage.WeakerRole(role, user.Role) != role {
+		return fmt.Errorf("role '%s' exceeds role '%s' of the user", role, user.Role)
+	}
+
+	key, err := auth.NewToken()
+	if err != nil {
+		return err
+	}
+	if _, err = db.CreateApiKey(user.Id, keyRequest.Name, role, auth.HashToken(key)); err != nil {
+		return err
+	}
+
+	log.Info("Api key is created", slog.String("user", user.Name), slog.String("role", role))
+	fmt.Println(key)
+	return nil
+}
+
+// isConflict reports whether err is storage error with 409 status
+func isConflict(err error) bool {
+	var errSql storage.SqlError
+	return errors.As(err, &errSql) && errSql.GetCode() == http.StatusConflict
+}
+
+// usageError wrong subcommand, flags or args
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+// parseFlags parses flags of the subcommand, args - number of expected args after flags
+func parseFlags(flags *flag.FlagSet, args []string, count int) error {
+	flags.SetOutput(os.Stderr)
+	if err := flags.Parse(args); err != nil {
+		return &usageError{err.Error()}
+	}
+	if flags.NArg() != count {
+		return &usageError{fmt.Sprintf("expect %d args, given: %d", count, flags.NArg())}
+	}
+	return nil
+}
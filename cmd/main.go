@@ -1,55 +1,105 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"github.com/go-chi/chi"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
 	"log/slog"
+	"net/http"
 	"os"
+	"sort"
+	"strings"
 	_ "web/docs"
 	"web/internal/config"
 	"web/internal/logging"
 	"web/internal/server/middleware"
+	"web/internal/server/policy"
 	"web/internal/server/server"
-	"web/internal/server/server/handlers"
+	"web/internal/server/ui"
 	"web/internal/storage"
+	"web/internal/storage/filesystem"
 	"web/internal/storage/sqlite"
 )
 
 // Todo Exclude tag from query
 
-// Todo delete h.AllTags param and make it in struct or other ideas
 // Todo delete task after 2 days of due date
 // Todo work from due date format make it more simple
-// Todo Update task handler + route + storage
 
 // @title Swagger Todo App Application
 // @version 1.0
 // @description API Server for Todo Application
 // @host localhost:8000
 // @BasePath /
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Session token from /user/login in format: Bearer <token>
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description Api key from /user/apikey
 
+// defaultConfigPath config file used without -config flag
+const defaultConfigPath = "config/config.yaml"
+
+// main runs subcommand: todo-server [-config path] [serve|migrate|seed|backup|restore|check|user|apikey] [flags] [args].
+// Server is started without subcommand
 func main() {
 	// Setup logger
 	log := logging.SetupLogger()
 
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	configPath := flags.String("config", defaultConfigPath, "path of config file")
+	flags.Usage = func() { usage(flags) }
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	name := "serve"
+	if flags.NArg() > 0 {
+		name = flags.Arg(0)
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		log.Error(fmt.Sprintf("Unknown command: %v", name))
+		usage(flags)
+		os.Exit(2)
+	}
+	var args []string
+	if flags.NArg() > 0 {
+		args = flags.Args()[1:]
+	}
+
 	// Read config file and create new Config{}
-	cfg := config.NewConfig("config/config.yaml", log)
-
-	// Create Sql connection
-	SqlDataBase := SqlConnect(cfg, log)
-
-	// Create new server
-	httpServer := server.NewServer(&SqlDataBase, log)
-	// Init handlers
-	allHandlers := handlers.NewHandlers(httpServer)
-	httpServer.InitHandlers(allHandlers)
-	// init middlewares
-	initMiddlewares(httpServer)
-	// Init routes
-	initRoutes(httpServer)
-	// Start server
-	httpServer.Start(cfg, log)
+	cfg := config.NewConfig(*configPath, log)
+
+	if err := cmd.run(cfg, log, args); err != nil {
+		log.Error(fmt.Sprintf("%v: %v", name, err.Error()))
+		var errUsage *usageError
+		if errors.As(err, &errUsage) {
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// usage prints subcommands and global flags
+func usage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintf(out, "Usage: %s [-config path] <command> [flags] [args]\n\nCommands:\n", flags.Name())
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(out, "\nFlags:")
+	flags.PrintDefaults()
 }
 
 func initMiddlewares(server *server.Server) {
@@ -58,57 +108,222 @@ func initMiddlewares(server *server.Server) {
 	router.Use(middleware.PanicRecovery(server.Log))
 	// request execution time
 	router.Use(middleware.HandlerExecutionTime(server.Log))
+	// content security policy of the API and web UI
+	router.Use(middleware.SecurityHeaders(middleware.ApiCSP))
 }
 
-// initRoutes init routes for server
+// initRoutes init routes for server.
+// Each authenticated route checks permission of the user role, see web/internal/server/policy
 func initRoutes(server *server.Server) {
 	router := server.Router
-	router.Route("/task", func(r chi.Router) {
+	can := middleware.RequirePermission
+
+	router.Route("/user", func(r chi.Router) {
+		// register new user, the first user becomes admin
+		// request body example:
+		// {"name": "name", "password": "password"}
+		r.Post("/register", server.Handlers.RegisterHandler)
+		// login, returns session token for 'Authorization: Bearer <token>' header
+		r.Post("/login", server.Handlers.LoginHandler)
+
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.Authenticate(server.Db))
+			r.Post("/logout", server.Handlers.LogoutHandler)
+			r.Get("/me", server.Handlers.GetMeHandler)
+
+			// api keys for 'X-API-Key: <key>' header
+			// request body example:
+			// {"name": "ci", "role": "read-only"}
+			r.Get("/apikey", server.Handlers.GetApiKeysHandler)
+			r.With(can(policy.KeysWrite)).Post("/apikey", server.Handlers.CreateApiKeyHandler)
+			r.With(can(policy.KeysWrite)).Delete("/apikey/{id:[0-9]+}", server.Handlers.DeleteApiKeyHandler)
+
+			// change role of the user
+			// request body example:
+			// {"role": "read-only"}
+			r.With(can(policy.UsersManage)).Put("/{name}/role", server.Handlers.SetUserRoleHandler)
+
+			// secret token of calendar feed, creating new token replaces old one.
+			// Token is credential like api key, so read-only key can't create it
+			r.With(can(policy.KeysWrite)).Post("/calendar", server.Handlers.CreateCalendarTokenHandler)
+			r.With(can(policy.KeysWrite)).Delete("/calendar", server.Handlers.DeleteCalendarTokenHandler)
+		})
+	})
+	// all tasks and tags belong to the user or shared with him, so all routes below require authentication
+	authenticated := router.With(middleware.Authenticate(server.Db))
+	authenticated.Route("/task", func(r chi.Router) {
+		// all get routes accept optional project query param to get only tasks of the project
+		// and assignee query param to get only tasks assigned to the user with this name,
+		// priority query param (P0-P4 separated by ',') and sort query param: priority, due or position,
+		// ready=true query param returns only not done tasks without not done blockers,
+		// due_from and due_to query params in format 2006-01-02T15:04:05Z return only tasks due in [due_from, due_to)
 		// get all tasks
-		r.Get("/", server.Handlers.GetTasksHandler)
+		r.With(can(policy.TasksRead)).Get("/", server.Handlers.GetTasksHandler)
+		// get tasks assigned to current user across all projects
+		r.With(can(policy.TasksRead)).Get("/assigned", server.Handlers.GetAssignedTasksHandler)
+		// get tasks with dependencies and dependencies between them
+		r.With(can(policy.TasksRead)).Get("/graph", server.Handlers.GetDependencyGraphHandler)
 		// get task by id
-		r.Get("/{id:\\d*}", server.Handlers.GetTaskHandler)
+		r.With(can(policy.TasksRead)).Get("/{id:\\d*}", server.Handlers.GetTaskHandler)
 		// get all tasks by tag or tags list with different modes.
 		// full - returns tasks who have  includes specified tag or tags list in the task tags.
 		// short - returns tasks who have only specified tag or tags list included in the task tags.
 		// tags - in query using , as separator
 		// due - in query format: 2006-01-02T15:04:05Z
 		r.Route("/tag", func(r chi.Router) {
-			r.Get("/{mode:(?:short|full)}/", server.Handlers.GetTasksByModeAndTagHandler)
-			r.Get("/", server.Handlers.GetTasksByTagHandler)
+			r.With(can(policy.TasksRead)).Get("/{mode:(?:short|full)}/", server.Handlers.GetTasksByModeAndTagHandler)
+			r.With(can(policy.TasksRead)).Get("/", server.Handlers.GetTasksByTagHandler)
+			// add and remove tags of tasks matching the same query params as get routes above
+			// request body example:
+			// {"add": ["q3"], "remove": ["backlog"], "dry_run": true}
+			r.With(can(policy.TasksWrite)).Post("/{mode:(?:short|full)}/bulk", server.Handlers.BulkTagHandler)
+			r.With(can(policy.TasksWrite)).Post("/bulk", server.Handlers.BulkTagHandler)
 		})
 		// get tasks by due date
-		r.Get("/{due:[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}(?::|%3A)[0-9]{2}(?::|%3A)[0-9]{2}Z}", server.Handlers.GetTasksByDueDateHandler)
+		r.With(can(policy.TasksRead)).Get("/{due:[0-9]{4}-[0-9]{2}-[0-9]{2}T[0-9]{2}(?::|%3A)[0-9]{2}(?::|%3A)[0-9]{2}Z}", server.Handlers.GetTasksByDueDateHandler)
 
 		// create new task using request body data
 		// request body example:
-		// {"text": "text", "tags": ["tag", "tag", "tag"], "due": "2021-01-01 00:00:00"}
-		r.Post("/", server.Handlers.CreateTaskHandler)
+		// {"text": "text", "tags": ["tag", "tag", "tag"], "due": "2021-01-01 00:00:00", "project_id": 1, "assignee": "name", "priority": "P1", "parent_id": 2}
+		r.With(can(policy.TasksWrite)).Post("/", server.Handlers.CreateTaskHandler)
+		// change fields of the task, missing fields are not changed
+		// request body example:
+		// {"assignee": "name", "done": true}
+		r.With(can(policy.TasksWrite)).Patch("/{id:[0-9]+}", server.Handlers.UpdateTaskHandler)
+
+		// move task right before or right after other task in order of sort=position
+		// request body example:
+		// {"before": 2}
+		r.With(can(policy.TasksWrite)).Post("/{id:[0-9]+}/move", server.Handlers.MoveTaskHandler)
 
-		// delete task by id
-		r.Delete("/{id:[0-9]*}", server.Handlers.DeleteTaskHandler)
-		// delete all tasks
-		r.Delete("/", server.Handlers.DeleteTasksHandler)
+		// task with subtasks of any depth and checklists, done part of subtasks and checklist items
+		r.With(can(policy.TasksRead)).Get("/{id:[0-9]+}/tree", server.Handlers.GetTaskTreeHandler)
+		r.With(can(policy.TasksRead)).Get("/{id:[0-9]+}/progress", server.Handlers.GetTaskProgressHandler)
+
+		// changes of the task and its parts with snapshots before and after change
+		r.With(can(policy.TasksRead)).Get("/{id:[0-9]+}/history", server.Handlers.GetTaskHistoryHandler)
+
+		// task from request body blocks task from path until it is done
+		// request body example:
+		// {"task_id": 2}
+		r.With(can(policy.TasksWrite)).Post("/{id:[0-9]+}/blocker", server.Handlers.AddDependencyHandler)
+		r.With(can(policy.TasksWrite)).Delete("/{id:[0-9]+}/blocker/{blocker:[0-9]+}", server.Handlers.RemoveDependencyHandler)
+
+		// checklist of the task
+		// request body example:
+		// {"text": "step", "done": true}
+		r.With(can(policy.TasksRead)).Get("/{id:[0-9]+}/checklist", server.Handlers.GetChecklistHandler)
+		r.With(can(policy.TasksWrite)).Post("/{id:[0-9]+}/checklist", server.Handlers.CreateChecklistItemHandler)
+		r.With(can(policy.TasksWrite)).Patch("/{id:[0-9]+}/checklist/{item:[0-9]+}", server.Handlers.UpdateChecklistItemHandler)
+		r.With(can(policy.TasksWrite)).Delete("/{id:[0-9]+}/checklist/{item:[0-9]+}", server.Handlers.DeleteChecklistItemHandler)
+
+		// comments of the task, text is markdown
+		// request body example:
+		// {"text": "**done** in review"}
+		r.With(can(policy.TasksRead)).Get("/{id:[0-9]+}/comment", server.Handlers.GetCommentsHandler)
+		r.With(can(policy.TasksWrite)).Post("/{id:[0-9]+}/comment", server.Handlers.CreateCommentHandler)
+		r.With(can(policy.TasksWrite)).Delete("/{id:[0-9]+}/comment/{comment:[0-9]+}", server.Handlers.DeleteCommentHandler)
+
+		// attachments of the task, file is uploaded as multipart/form-data with 'file' field
+		r.With(can(policy.TasksRead)).Get("/{id:[0-9]+}/attachment", server.Handlers.GetAttachmentsHandler)
+		r.With(can(policy.TasksWrite)).Post("/{id:[0-9]+}/attachment", server.Handlers.CreateAttachmentHandler)
+		r.With(can(policy.TasksRead)).Get("/{id:[0-9]+}/attachment/{attachment:[0-9]+}", server.Handlers.DownloadAttachmentHandler)
+		r.With(can(policy.TasksWrite)).Delete("/{id:[0-9]+}/attachment/{attachment:[0-9]+}", server.Handlers.DeleteAttachmentHandler)
+
+		// share task with the user, permission: read or write
+		// request body example:
+		// {"user": "name", "permission": "read"}
+		r.With(can(policy.TasksRead)).Get("/{id:[0-9]+}/share", server.Handlers.GetTaskSharesHandler)
+		r.With(can(policy.TasksWrite)).Post("/{id:[0-9]+}/share", server.Handlers.ShareTaskHandler)
+		r.With(can(policy.TasksWrite)).Delete("/{id:[0-9]+}/share/{name}", server.Handlers.UnshareTaskHandler)
+
+		// create, update, delete and complete tasks in one transaction, mode: atomic (default) or partial
+		// request body example:
+		// {"mode": "partial", "operations": [{"op": "create", "task": {"text": "a", "tags": ["work"], "due": "2030-01-01T00:00:00Z"}},
+		// {"op": "update", "id": 1, "changes": {"priority": "P0"}}, {"op": "complete", "id": 2}, {"op": "delete", "id": 3}]}
+		r.With(can(policy.TasksWrite)).Post("/batch", server.Handlers.BatchTasksHandler)
+
+		// download tasks matching filter as file, format: csv (default), ics, todotxt or markdown,
+		// component of ics: todo (default) or event, group of markdown: tag (default) or due
+		r.With(can(policy.TasksRead)).Get("/export", server.Handlers.ExportTasksHandler)
+		// create tasks from file in body, format: csv (default), ics, todotxt or markdown, create_tags: true or false,
+		// map: csv columns 'Title:text,Labels:tags', ics categories, todotxt projects and contexts or markdown tags to tags 'Business:work'
+		// csv body example:
+		// text,tags,due
+		// a,work; q3,2030-01-01T00:00:00Z
+		r.With(can(policy.TasksWrite)).Post("/import", server.Handlers.ImportTasksHandler)
+
+		// move task by id to trash
+		r.With(can(policy.TasksWrite)).Delete("/{id:[0-9]*}", server.Handlers.DeleteTaskHandler)
+		// move all own tasks to trash, admin only
+		r.With(can(policy.TasksDeleteAll)).Delete("/", server.Handlers.DeleteTasksHandler)
 	})
-	router.Route("/tag", func(r chi.Router) {
+	authenticated.Route("/project", func(r chi.Router) {
+		// get all projects
+		r.With(can(policy.ProjectsRead)).Get("/", server.Handlers.GetProjectsHandler)
+		// get project by id
+		r.With(can(policy.ProjectsRead)).Get("/{id:[0-9]+}", server.Handlers.GetProjectHandler)
+
+		// create new project, rename project
+		// request body example:
+		// {"name": "release"}
+		r.With(can(policy.ProjectsWrite)).Post("/", server.Handlers.CreateProjectHandler)
+		r.With(can(policy.ProjectsWrite)).Put("/{id:[0-9]+}", server.Handlers.UpdateProjectHandler)
+
+		// delete project without tasks and tags, they are deleted through trash
+		r.With(can(policy.ProjectsWrite)).Delete("/{id:[0-9]+}", server.Handlers.DeleteProjectHandler)
+
+		// share project and all its tasks with the user, permission: read or write
+		// request body example:
+		// {"user": "name", "permission": "write"}
+		r.With(can(policy.ProjectsRead)).Get("/{id:[0-9]+}/share", server.Handlers.GetProjectSharesHandler)
+		r.With(can(policy.ProjectsWrite)).Post("/{id:[0-9]+}/share", server.Handlers.ShareProjectHandler)
+		r.With(can(policy.ProjectsWrite)).Delete("/{id:[0-9]+}/share/{name}", server.Handlers.UnshareProjectHandler)
+	})
+	// tags belong to the project, project query param selects tags of the project, personal tags if not set
+	authenticated.Route("/tag", func(r chi.Router) {
 		// get all tags
-		r.Get("/", server.Handlers.GetTagsHandler)
+		r.With(can(policy.TagsRead)).Get("/", server.Handlers.GetTagsHandler)
 		// get tag by name
-		r.Get("/{name:[A-Za-z]+}", server.Handlers.GetTagHandler)
+		r.With(can(policy.TagsRead)).Get("/{name:[A-Za-z]+}", server.Handlers.GetTagHandler)
 
 		// create new tag using request body data
 		// request body example:
-		// {"name": "name"}
-		r.Post("/", server.Handlers.CreateTagHandler)
+		// {"name": "name", "project_id": 1}
+		r.With(can(policy.TagsWrite)).Post("/", server.Handlers.CreateTagHandler)
 
-		// delete all tags
-		r.Delete("/", server.Handlers.DeleteTagsHandler)
-		// delete tag by name
-		r.Delete("/{name:[A-Za-z]+}", server.Handlers.DeleteTagHandler)
+		// move all tags of the project to trash, admin only
+		r.With(can(policy.TagsDeleteAll)).Delete("/", server.Handlers.DeleteTagsHandler)
+		// move tag by name to trash
+		r.With(can(policy.TagsWrite)).Delete("/{name:[A-Za-z]+}", server.Handlers.DeleteTagHandler)
+	})
+	// deleted tasks and tags, they are purged automatically after retention from config
+	authenticated.Route("/trash", func(r chi.Router) {
+		r.With(can(policy.TasksRead)).Get("/", server.Handlers.GetTrashHandler)
+		r.With(can(policy.TasksWrite)).Post("/{id:[0-9]+}/restore", server.Handlers.RestoreTaskHandler)
+		r.With(can(policy.TagsWrite)).Post("/tag/{id:[0-9]+}/restore", server.Handlers.RestoreTagHandler)
+		// delete trashed tasks and tags forever
+		r.With(can(policy.TasksWrite)).Delete("/", server.Handlers.EmptyTrashHandler)
 	})
+	// reverse the newest operation of the user, query param token - undo token of other operation
+	authenticated.With(can(policy.TasksWrite)).Post("/undo", server.Handlers.UndoHandler)
+	// changes of all users, admin only.
+	// query params: actor, entity, entity_id, task, from and to in format: 2006-01-02T15:04:05Z, limit
+	authenticated.With(can(policy.AuditRead)).Get("/audit", server.Handlers.GetAuditLogHandler)
+	// snapshot of the database, admin only. Restore replaces all data including users and sessions with snapshot in body
+	authenticated.Route("/admin", func(r chi.Router) {
+		r.With(can(policy.DatabaseManage)).Post("/backup", server.Handlers.BackupHandler)
+		r.With(can(policy.DatabaseManage)).Post("/restore", server.Handlers.RestoreHandler)
+	})
+	// iCalendar feed of tasks for calendar apps, they can't send headers, so user is authenticated by token query param.
+	// query params: token - calendar token from POST /user/calendar, component: todo (default) or event, filters of GET /task/export
+	router.With(middleware.AuthenticateCalendar(server.Db), can(policy.TasksRead)).Get("/calendar.ics", server.Handlers.CalendarHandler)
 	router.MethodNotAllowed(server.Handlers.MethodNotAllowedHandler)
 	router.NotFound(server.Handlers.NotFoundHandler)
-	router.Get("/swagger/*", httpSwagger.Handler())
+	router.With(middleware.SecurityHeaders(middleware.SwaggerCSP)).Get("/swagger/*", httpSwagger.Handler())
+	// web UI, its static files have no data, UI logs in by /user/login and uses routes above with session token
+	router.Get(strings.TrimSuffix(ui.Path, "/"), http.RedirectHandler(ui.Path, http.StatusMovedPermanently).ServeHTTP)
+	router.Handle(ui.Path+"*", ui.Handler())
 
 }
 
@@ -116,16 +331,34 @@ func initRoutes(server *server.Server) {
 func SqlConnect(cfg *config.Config, log *slog.Logger) storage.Storage {
 	var sqlStorage storage.Storage
 
-	switch cfg.Type {
+	switch cfg.DatabaseConfig.Type {
 	case "sqlite":
 		sqlStorage = &sqlite.StoreSqlite{}
 	default:
-		log.Error(fmt.Sprintf("Unknown database type: %v", cfg.Type))
+		log.Error(fmt.Sprintf("Unknown database type: %v", cfg.DatabaseConfig.Type))
 		os.Exit(1)
 	}
 
 	db := sqlStorage.Connect(cfg, log)
 
-	log.Info("Successfully connected to database", slog.String("type", cfg.Type))
+	log.Info("Successfully connected to database", slog.String("type", cfg.DatabaseConfig.Type))
 	return db
 }
+
+// BlobConnect open blob store from config
+func BlobConnect(cfg *config.Config, log *slog.Logger) storage.BlobStore {
+	var blobStore storage.BlobStore
+
+	switch cfg.BlobStore.Type {
+	case "local":
+		blobStore = &filesystem.BlobStoreFs{}
+	default:
+		log.Error(fmt.Sprintf("Unknown blob store type: %v", cfg.BlobStore.Type))
+		os.Exit(1)
+	}
+
+	blobs := blobStore.Connect(cfg, log)
+
+	log.Info("Successfully opened blob store", slog.String("type", cfg.BlobStore.Type))
+	return blobs
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// names of special keys, printable key has empty name
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyBackspace = "backspace"
+	keyTab       = "tab"
+	keyCtrlC     = "ctrl-c"
+	keyCtrlU     = "ctrl-u"
+)
+
+// key pressed key, name - special key, r - printable character
+type key struct {
+	name string
+	r    rune
+}
+
+// arrows final bytes of arrow escape sequences
+var arrows = map[byte]string{'A': keyUp, 'B': keyDown, 'C': keyRight, 'D': keyLeft}
+
+// readKeys reads keys from terminal in raw mode until error, channel is closed after error
+func readKeys(r io.Reader, keys chan<- key) {
+	defer close(keys)
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			return
+		}
+		for _, k := range parseKeys(buf[:n]) {
+			keys <- k
+		}
+	}
+}
+
+// parseKeys returns keys of one read, escape sequence is read at once, so lone ESC is escape key
+func parseKeys(data []byte) []key {
+	var keys []key
+	for len(data) > 0 {
+		switch b := data[0]; {
+		case b == 0x1b:
+			// ESC [ A - arrow, ESC O A - arrow in application mode, other sequences are skipped
+			if len(data) >= 3 && (data[1] == '[' || data[1] == 'O') {
+				if name, ok := arrows[data[2]]; ok {
+					keys = append(keys, key{name: name})
+				}
+				end := 2
+				for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+					end++
+				}
+				data = data[min(end+1, len(data)):]
+				continue
+			}
+			keys = append(keys, key{name: keyEscape})
+		case b == '\r' || b == '\n':
+			keys = append(keys, key{name: keyEnter})
+		case b == 0x7f || b == 0x08:
+			keys = append(keys, key{name: keyBackspace})
+		case b == '\t':
+			keys = append(keys, key{name: keyTab})
+		case b == 0x03:
+			keys = append(keys, key{name: keyCtrlC})
+		case b == 0x15:
+			keys = append(keys, key{name: keyCtrlU})
+		case b < 0x20:
+			// other control keys are skipped
+		default:
+			r, size := utf8.DecodeRune(data)
+			keys = append(keys, key{r: r})
+			data = data[size:]
+			continue
+		}
+		data = data[1:]
+	}
+	return keys
+}
@@ -0,0 +1,59 @@
+// todo-tui is interactive terminal client of the API.
+//
+// Usage:
+//
+//	todo-tui [-config path] [-refresh 5s]
+//
+// Config file is config of the todo command-line client, see web/internal/client.
+// Tasks are shown in a list with tag sidebar, overdue tasks are red and tasks due today are yellow.
+// New and edited tasks are entered in todo.txt syntax: '(A) text +tag due:2006-01-02'
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+	"web/internal/client"
+)
+
+// defaultRefresh interval of tasks reload
+const defaultRefresh = 5 * time.Second
+
+func main() {
+	os.Exit(run())
+}
+
+// run runs UI and returns exit code, terminal is restored before exit
+func run() int {
+	configPath := flag.String("config", client.DefaultConfigPath(), "path of config file, env "+client.ConfigEnv)
+	refresh := flag.Duration("refresh", defaultRefresh, "interval of tasks reload")
+	flag.Parse()
+	if *refresh <= 0 {
+		fmt.Fprintln(os.Stderr, "todo-tui: refresh must be positive")
+		return 2
+	}
+
+	cfg, err := client.ReadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "todo-tui: %v\n", err)
+		return 1
+	}
+
+	stdin, stdout := int(os.Stdin.Fd()), int(os.Stdout.Fd())
+	state, err := makeRaw(stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "todo-tui: terminal is required: %v\n", err)
+		return 1
+	}
+	defer func() { _ = restore(stdin, state) }()
+
+	keys := make(chan key)
+	go readKeys(os.Stdin, keys)
+	if err = newUI(client.New(cfg), os.Stdout, stdout).run(keys, *refresh); err != nil {
+		_ = restore(stdin, state)
+		fmt.Fprintf(os.Stderr, "todo-tui: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,29 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package main
+
+import (
+	"errors"
+	"runtime"
+)
+
+// errNoTerminal raw mode is not supported by the system
+var errNoTerminal = errors.New("terminal is not supported on " + runtime.GOOS)
+
+// terminalState settings of the terminal to restore after raw mode
+type terminalState struct{}
+
+// makeRaw puts terminal into raw mode, not supported by the system
+func makeRaw(fd int) (*terminalState, error) {
+	return nil, errNoTerminal
+}
+
+// restore returns terminal to the state before raw mode
+func restore(fd int, state *terminalState) error {
+	return errNoTerminal
+}
+
+// terminalSize returns width and height of the terminal in characters
+func terminalSize(fd int) (int, int, error) {
+	return 0, 0, errNoTerminal
+}
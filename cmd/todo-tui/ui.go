@@ -0,0 +1,584 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/fatih/color"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"web/internal/client"
+	"web/internal/format"
+	"web/internal/server/context/request"
+	"web/internal/storage"
+)
+
+// colors of the screen follow colors of log levels in web/internal/logging/lib/colorLog.go:
+// cyan - titles as log messages, green - done actions as info, yellow - tasks due today as warnings,
+// red - overdue tasks and errors, blue - done tasks as debug. Selected item is shown in reverse video
+var (
+	titleColor    = color.New(color.FgCyan)
+	okColor       = color.New(color.FgGreen)
+	warnColor     = color.New(color.FgYellow)
+	errorColor    = color.New(color.FgRed)
+	doneColor     = color.New(color.FgBlue)
+	textColor     = color.New(color.FgWhite)
+	selectedColor = color.New(color.ReverseVideo)
+)
+
+// tagAll sidebar item of all tasks
+const tagAll = "all"
+
+// sidebarWidth max width of tag sidebar
+const sidebarWidth = 20
+
+// dateFormat due date without time, due of the date is midnight UTC
+const dateFormat = "2006-01-02"
+
+// help keys of the list
+const help = "j/k move  tab tags  a add  e edit  x done  d delete  r refresh  q quit"
+
+// focus part of the screen receiving keys
+type focus int
+
+const (
+	focusList focus = iota
+	focusTags
+)
+
+// prompt line input, submit runs on enter with entered text
+type prompt struct {
+	label  string
+	text   []rune
+	submit func(text string)
+}
+
+// loadResult tasks and tags loaded in background
+type loadResult struct {
+	tasks []storage.Task
+	tags  []storage.Tag
+	err   error
+}
+
+// ui state of the terminal UI
+type ui struct {
+	api *client.Client
+	out *bufio.Writer
+	fd  int
+
+	tasks []storage.Task
+	// tags tags of sidebar, the first one is tagAll
+	tags []string
+	// tag index of selected tag
+	tag int
+	// cursor index of selected task in visible tasks, offset - index of the first shown task
+	cursor int
+	offset int
+	focus  focus
+
+	prompt *prompt
+	// confirm runs if 'y' is pressed, any other key cancels it
+	confirm      func()
+	confirmLabel string
+
+	status    string
+	statusErr bool
+	loadedAt  time.Time
+	loading   bool
+	loaded    chan loadResult
+}
+
+// newUI returns UI of the terminal, fd - file descriptor of the terminal output
+func newUI(api *client.Client, out io.Writer, fd int) *ui {
+	return &ui{
+		api:    api,
+		out:    bufio.NewWriter(out),
+		fd:     fd,
+		tags:   []string{tagAll},
+		loaded: make(chan loadResult, 1),
+	}
+}
+
+// run shows UI until quit key or end of input, tasks are reloaded every refresh
+func (u *ui) run(keys <-chan key, refresh time.Duration) error {
+	// alternate screen keeps content of the terminal, cursor is hidden
+	u.out.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		u.out.WriteString("\x1b[?25h\x1b[?1049l")
+		_ = u.out.Flush()
+	}()
+
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+	u.load()
+	for {
+		if err := u.render(); err != nil {
+			return err
+		}
+		select {
+		case k, ok := <-keys:
+			if !ok || u.handleKey(k) {
+				return nil
+			}
+		case result := <-u.loaded:
+			u.apply(result)
+		case <-ticker.C:
+			u.load()
+		}
+	}
+}
+
+// load loads tasks and tags in background, result is applied by run loop
+func (u *ui) load() {
+	if u.loading {
+		return
+	}
+	u.loading = true
+	go func() {
+		var result loadResult
+		result.tasks, result.err = u.api.Tasks(&client.TaskQuery{Sort: "due"})
+		if result.err == nil {
+			result.tags, result.err = u.api.Tags(0)
+		}
+		u.loaded <- result
+	}()
+}
+
+// apply shows loaded tasks and tags, selected tag and task are kept if they still exist
+func (u *ui) apply(result loadResult) {
+	u.loading = false
+	if result.err != nil {
+		u.setError(result.err)
+		return
+	}
+
+	selectedTag := u.tags[u.tag]
+	selectedTask := -1
+	if visible := u.visible(); u.cursor < len(visible) {
+		selectedTask = visible[u.cursor].Id
+	}
+
+	u.tasks = result.tasks
+	names := make(map[string]bool)
+	for _, tag := range result.tags {
+		names[tag.Name] = true
+	}
+	for _, task := range u.tasks {
+		for _, tag := range task.Tags {
+			if tag != "" {
+				names[tag] = true
+			}
+		}
+	}
+	tags := make([]string, 0, len(names))
+	for name := range names {
+		tags = append(tags, name)
+	}
+	slices.Sort(tags)
+	u.tags = append([]string{tagAll}, tags...)
+
+	u.tag = max(slices.Index(u.tags, selectedTag), 0)
+	u.cursor = 0
+	for i, task := range u.visible() {
+		if task.Id == selectedTask {
+			u.cursor = i
+		}
+	}
+	u.loadedAt = time.Now()
+}
+
+// visible returns tasks with selected tag
+func (u *ui) visible() []storage.Task {
+	if u.tag == 0 {
+		return u.tasks
+	}
+	var tasks []storage.Task
+	for _, task := range u.tasks {
+		if slices.Contains(task.Tags, u.tags[u.tag]) {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// selected returns selected task, nil if list is empty
+func (u *ui) selected() *storage.Task {
+	visible := u.visible()
+	if u.cursor >= len(visible) {
+		return nil
+	}
+	return &visible[u.cursor]
+}
+
+// handleKey changes state by the key, returns true to quit
+func (u *ui) handleKey(k key) bool {
+	if k.name == keyCtrlC {
+		return true
+	}
+	if u.confirm != nil {
+		confirm := u.confirm
+		u.confirm = nil
+		if k.r == 'y' || k.r == 'Y' {
+			confirm()
+		}
+		return false
+	}
+	if u.prompt != nil {
+		u.handlePromptKey(k)
+		return false
+	}
+
+	if u.focus == focusTags {
+		switch {
+		case k.name == keyUp || k.r == 'k':
+			u.tag = max(u.tag-1, 0)
+			u.cursor = 0
+		case k.name == keyDown || k.r == 'j':
+			u.tag = min(u.tag+1, len(u.tags)-1)
+			u.cursor = 0
+		case k.name == keyTab || k.name == keyEnter || k.name == keyRight || k.name == keyEscape || k.r == 'l':
+			u.focus = focusList
+		case k.r == 'q':
+			return true
+		}
+		return false
+	}
+
+	switch {
+	case k.name == keyUp || k.r == 'k':
+		u.cursor = max(u.cursor-1, 0)
+	case k.name == keyDown || k.r == 'j':
+		u.cursor = max(min(u.cursor+1, len(u.visible())-1), 0)
+	case k.name == keyTab || k.name == keyLeft || k.r == 'h':
+		u.focus = focusTags
+	case k.r == 'a':
+		u.startCreate()
+	case k.r == 'e' || k.name == keyEnter:
+		u.startEdit()
+	case k.r == 'x' || k.r == ' ':
+		u.toggleDone()
+	case k.r == 'd':
+		u.startDelete()
+	case k.r == 'r':
+		u.load()
+	case k.r == 'q':
+		return true
+	}
+	return false
+}
+
+// handlePromptKey edits text of the prompt
+func (u *ui) handlePromptKey(k key) {
+	switch k.name {
+	case keyEnter:
+		p := u.prompt
+		u.prompt = nil
+		p.submit(strings.TrimSpace(string(p.text)))
+	case keyEscape:
+		u.prompt = nil
+	case keyBackspace:
+		if len(u.prompt.text) > 0 {
+			u.prompt.text = u.prompt.text[:len(u.prompt.text)-1]
+		}
+	case keyCtrlU:
+		u.prompt.text = nil
+	case "":
+		u.prompt.text = append(u.prompt.text, k.r)
+	}
+}
+
+// startCreate asks task in todo.txt syntax, task gets selected tag if it has no tags and due tomorrow if it has no due
+func (u *ui) startCreate() {
+	u.prompt = &prompt{label: "new task ((A) text +tag due:2006-01-02)", submit: func(text string) {
+		if text == "" {
+			return
+		}
+		task, err := format.ParseTodoTxtLine(text, nil)
+		if err != nil {
+			u.setError(err)
+			return
+		}
+		if len(task.Tags) == 0 && u.tag != 0 {
+			task.Tags = []string{u.tags[u.tag]}
+		}
+		if len(task.Tags) == 0 {
+			u.setError(errors.New("task needs +tag"))
+			return
+		}
+		if task.Due == "" {
+			task.Due = time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, 1).Format(time.RFC3339)
+		}
+		if err = u.createTags(0, task.Tags); err != nil {
+			u.setError(err)
+			return
+		}
+
+		created, err := u.api.CreateTask(&request.TaskRequest{Text: task.Text, Tags: task.Tags, Due: task.Due,
+			Priority: task.Priority})
+		if err != nil {
+			u.setError(err)
+			return
+		}
+		u.setStatus(fmt.Sprintf("created task %d", created.Id))
+		u.load()
+	}}
+}
+
+// startEdit asks new text, tags, due and priority of selected task in todo.txt syntax
+func (u *ui) startEdit() {
+	task := u.selected()
+	if task == nil {
+		return
+	}
+	id, projectId := task.Id, task.ProjectId
+	u.prompt = &prompt{label: fmt.Sprintf("edit task %d", id), text: []rune(format.TodoTxtLine(*task)), submit: func(text string) {
+		if text == "" {
+			return
+		}
+		changed, err := format.ParseTodoTxtLine(text, nil)
+		if err != nil {
+			u.setError(err)
+			return
+		}
+		if err = u.createTags(projectId, changed.Tags); err != nil {
+			u.setError(err)
+			return
+		}
+
+		update := &request.TaskUpdateRequest{Text: &changed.Text, Tags: changed.Tags, Done: &changed.Done}
+		if changed.Due != "" {
+			update.Due = &changed.Due
+		}
+		if changed.Priority != "" {
+			update.Priority = &changed.Priority
+		}
+		if _, err = u.api.UpdateTask(id, update); err != nil {
+			u.setError(err)
+			return
+		}
+		u.setStatus(fmt.Sprintf("changed task %d", id))
+		u.load()
+	}}
+}
+
+// toggleDone marks selected task done or not done
+func (u *ui) toggleDone() {
+	task := u.selected()
+	if task == nil {
+		return
+	}
+	done := !task.Done
+	updated, err := u.api.UpdateTask(task.Id, &request.TaskUpdateRequest{Done: &done})
+	if err != nil {
+		u.setError(err)
+		return
+	}
+	// task is changed in place, so it doesn't jump before reload
+	*task = *updated
+	u.setStatus(fmt.Sprintf("task %d is %s", task.Id, map[bool]string{true: "done", false: "not done"}[done]))
+	u.load()
+}
+
+// startDelete asks confirmation and moves selected task to trash
+func (u *ui) startDelete() {
+	task := u.selected()
+	if task == nil {
+		return
+	}
+	id := task.Id
+	u.confirmLabel = fmt.Sprintf("delete task %d '%s'? (y/n)", id, task.Text)
+	u.confirm = func() {
+		if err := u.api.DeleteTask(id); err != nil {
+			u.setError(err)
+			return
+		}
+		u.setStatus(fmt.Sprintf("task %d moved to trash", id))
+		u.load()
+	}
+}
+
+// createTags creates tags of the project not shown in sidebar, existing tags are skipped
+func (u *ui) createTags(projectId int, tags []string) error {
+	for _, tag := range tags {
+		if slices.Contains(u.tags[1:], tag) {
+			continue
+		}
+		err := u.api.CreateTag(projectId, tag)
+		var errApi *client.Error
+		if err != nil && !(errors.As(err, &errApi) && errApi.Status == http.StatusConflict) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *ui) setStatus(text string) {
+	u.status, u.statusErr = text, false
+}
+
+func (u *ui) setError(err error) {
+	var errApi *client.Error
+	if errors.As(err, &errApi) && errApi.Status != 0 {
+		err = errors.New(errApi.Message)
+	}
+	u.status, u.statusErr = "error: "+err.Error(), true
+}
+
+// render draws whole screen: title, tag sidebar with task list, status or prompt line and help
+func (u *ui) render() error {
+	width, height, err := terminalSize(u.fd)
+	if err != nil || width < 20 || height < 5 {
+		width, height = 80, 24
+	}
+	side := min(sidebarWidth, width/4)
+	listWidth := width - side - 3
+	rows := height - 3
+
+	visible := u.visible()
+	if u.cursor < u.offset {
+		u.offset = u.cursor
+	}
+	if u.cursor >= u.offset+rows {
+		u.offset = u.cursor - rows + 1
+	}
+
+	u.out.WriteString("\x1b[H\x1b[2J")
+	title := fmt.Sprintf("Tasks: %s (%d)", u.tags[u.tag], len(visible))
+	if !u.loadedAt.IsZero() {
+		title += "  updated " + u.loadedAt.Format("15:04:05")
+	}
+	u.line(titleColor.Sprint(fit(title, width)))
+
+	now := time.Now()
+	for row := 0; row < rows; row++ {
+		tag := ""
+		tagColor := textColor
+		if row < len(u.tags) {
+			tag = u.tags[row]
+			if row == u.tag {
+				tag = "> " + tag
+				tagColor = titleColor
+			} else {
+				tag = "  " + tag
+			}
+		}
+		line := tagColor.Sprint(pad(fit(tag, side), side))
+		if row == u.tag && u.focus == focusTags {
+			line = selectedColor.Sprint(line)
+		}
+		line += " │ "
+
+		if i := u.offset + row; i < len(visible) {
+			text := taskColor(visible[i], now).Sprint(pad(fit(taskLine(visible[i]), listWidth), listWidth))
+			if i == u.cursor && u.focus == focusList {
+				text = selectedColor.Sprint(text)
+			}
+			line += text
+		}
+		u.line(line)
+	}
+
+	switch {
+	case u.prompt != nil:
+		u.line(titleColor.Sprint(u.prompt.label+": ") + fit(string(u.prompt.text), width-len(u.prompt.label)-3) + "_")
+	case u.confirm != nil:
+		u.line(warnColor.Sprint(fit(u.confirmLabel, width)))
+	case u.statusErr:
+		u.line(errorColor.Sprint(fit(u.status, width)))
+	default:
+		u.line(okColor.Sprint(fit(u.status, width)))
+	}
+	u.out.WriteString(textColor.Sprint(fit(help, width)))
+	return u.out.Flush()
+}
+
+// line writes line of the screen, raw terminal needs carriage return
+func (u *ui) line(text string) {
+	u.out.WriteString(text + "\r\n")
+}
+
+// taskLine returns task as line of the list: check, priority, due, text and tags
+func taskLine(task storage.Task) string {
+	check := "[ ]"
+	if task.Done {
+		check = "[x]"
+	}
+	words := []string{check, task.Priority, dueText(task.Due), task.Text}
+	for _, tag := range task.Tags {
+		if tag != "" {
+			words = append(words, "#"+tag)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// dueText returns due as date if due is midnight UTC, otherwise due in local time
+func dueText(due string) string {
+	parsed, err := time.Parse(time.RFC3339, due)
+	if err != nil {
+		return due
+	}
+	if isDate(parsed) {
+		return parsed.UTC().Format(dateFormat)
+	}
+	return parsed.Local().Format("2006-01-02 15:04")
+}
+
+// isDate reports whether due has no time, due without time is midnight UTC
+func isDate(due time.Time) bool {
+	due = due.UTC()
+	return due.Equal(due.Truncate(24 * time.Hour))
+}
+
+// taskColor returns color of the task: blue - done, red - overdue, yellow - due today
+func taskColor(task storage.Task, now time.Time) *color.Color {
+	due, err := time.Parse(time.RFC3339, task.Due)
+	switch {
+	case task.Done:
+		return doneColor
+	case err != nil:
+		return textColor
+	case isDate(due):
+		// task due on date is overdue after the date
+		today := now.Format(dateFormat)
+		date := due.UTC().Format(dateFormat)
+		if date < today {
+			return errorColor
+		}
+		if date == today {
+			return warnColor
+		}
+	case due.Before(now):
+		return errorColor
+	case due.Local().Format(dateFormat) == now.Format(dateFormat):
+		return warnColor
+	}
+	return textColor
+}
+
+// fit cuts text to width characters
+func fit(text string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	if width == 1 {
+		return string(runes[:1])
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// pad adds spaces to text up to width characters
+func pad(text string, width int) string {
+	if n := utf8.RuneCountInString(text); n < width {
+		return text + strings.Repeat(" ", width-n)
+	}
+	return text
+}
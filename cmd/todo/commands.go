@@ -0,0 +1,337 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"web/internal/client"
+	"web/internal/server/context/request"
+	"web/internal/storage"
+)
+
+// dateFormat due date without time, due of the date is midnight UTC
+const dateFormat = "2006-01-02"
+
+// tagModeAny mode of tag filter: tasks with any of the tags, other modes are modes of /task/tag/{mode}/
+const tagModeAny = "any"
+
+// newFlags returns flags of the command, parse errors are returned as *usageError
+func newFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+// parseFlags parses flags of the command, flags may be mixed with args, e.g. 'rm 1 2 -project 3'
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	for {
+		if err := flags.Parse(args); err != nil {
+			return nil, &usageError{fmt.Sprintf("%s: %v", flags.Name(), err)}
+		}
+		args = flags.Args()
+		if len(args) == 0 {
+			return rest, nil
+		}
+		rest = append(rest, args[0])
+		args = args[1:]
+	}
+}
+
+// taskFilters binds flags of task filters, returned function builds query after parsing
+func taskFilters(flags *flag.FlagSet) func() (*client.TaskQuery, error) {
+	query := &client.TaskQuery{}
+	tags := flags.String("tag", "", "tags separated by ','")
+	mode := flags.String("mode", tagModeAny, "tag mode: any - any of tags, full - all tags, short - only all tags")
+	due := flags.String("due", "", "exact due as 2006-01-02 or RFC3339, used with -tag")
+	flags.IntVar(&query.ProjectId, "project", 0, "project id")
+	flags.StringVar(&query.Assignee, "assignee", "", "assignee login")
+	flags.StringVar(&query.Priority, "priority", "", "priorities separated by ',', e.g. P0,P1")
+	flags.StringVar(&query.Sort, "sort", "", "sort order, e.g. due or priority")
+	flags.BoolVar(&query.Ready, "ready", false, "only tasks without not done blockers")
+	dueFrom := flags.String("due-from", "", "due from as 2006-01-02 or RFC3339")
+	dueTo := flags.String("due-to", "", "due to as 2006-01-02 or RFC3339")
+
+	return func() (*client.TaskQuery, error) {
+		query.Tags = splitList(*tags)
+		switch *mode {
+		case tagModeAny:
+		case "full", "short":
+			query.Mode = *mode
+		default:
+			return nil, &usageError{fmt.Sprintf("unknown tag mode '%s'", *mode)}
+		}
+		var err error
+		if query.Due, err = parseDue(*due); err != nil {
+			return nil, err
+		}
+		if query.DueFrom, err = parseDue(*dueFrom); err != nil {
+			return nil, err
+		}
+		if query.DueTo, err = parseDue(*dueTo); err != nil {
+			return nil, err
+		}
+		return query, nil
+	}
+}
+
+// addCommand creates task, text is args joined by space
+func addCommand(c *cli, args []string) error {
+	flags := newFlags("add")
+	tags := flags.String("tags", "", "tags separated by ','")
+	due := flags.String("due", "", "due as 2006-01-02 or RFC3339")
+	task := &request.TaskRequest{}
+	flags.StringVar(&task.Priority, "priority", "", "priority P0-P4")
+	flags.IntVar(&task.ProjectId, "project", 0, "project id")
+	flags.IntVar(&task.ParentId, "parent", 0, "parent task id")
+	flags.StringVar(&task.Assignee, "assignee", "", "assignee login")
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	task.Text = strings.Join(args, " ")
+	task.Tags = splitList(*tags)
+	if task.Due, err = parseDue(*due); err != nil {
+		return err
+	}
+	if task.Text == "" || len(task.Tags) == 0 || task.Due == "" {
+		return &usageError{"add: text, -tags and -due are required"}
+	}
+
+	created, err := c.api.CreateTask(task)
+	if err != nil {
+		return err
+	}
+	return c.out.task(*created)
+}
+
+// lsCommand prints tasks matching filters
+func lsCommand(c *cli, args []string) error {
+	flags := newFlags("ls")
+	filters := taskFilters(flags)
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return &usageError{"ls: unexpected args " + strings.Join(args, " ")}
+	}
+	query, err := filters()
+	if err != nil {
+		return err
+	}
+
+	tasks, err := c.api.Tasks(query)
+	if err != nil {
+		return err
+	}
+	return c.out.tasks(tasks)
+}
+
+// rmCommand moves tasks to trash
+func rmCommand(c *cli, args []string) error {
+	ids, err := parseIds("rm", args)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err = c.api.DeleteTask(id); err != nil {
+			return fmt.Errorf("task %d: %w", id, err)
+		}
+	}
+	return c.out.message(fmt.Sprintf("deleted %d task(s)", len(ids)), map[string][]int{"deleted": ids})
+}
+
+// doneCommand marks tasks done
+func doneCommand(c *cli, args []string) error {
+	ids, err := parseIds("done", args)
+	if err != nil {
+		return err
+	}
+	done := true
+	tasks := make([]storage.Task, 0, len(ids))
+	for _, id := range ids {
+		task, err := c.api.UpdateTask(id, &request.TaskUpdateRequest{Done: &done})
+		if err != nil {
+			return fmt.Errorf("task %d: %w", id, err)
+		}
+		tasks = append(tasks, *task)
+	}
+	return c.out.tasks(tasks)
+}
+
+// tagsCommand prints tags of the project
+func tagsCommand(c *cli, args []string) error {
+	flags := newFlags("tags")
+	projectId := flags.Int("project", 0, "project id, 0 - personal tags")
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return &usageError{"tags: unexpected args " + strings.Join(args, " ")}
+	}
+
+	tags, err := c.api.Tags(*projectId)
+	if err != nil {
+		return err
+	}
+	return c.out.tags(tags)
+}
+
+// tagCommand creates or deletes tags: 'tag add name...' and 'tag rm name...'
+func tagCommand(c *cli, args []string) error {
+	if len(args) == 0 || (args[0] != "add" && args[0] != "rm") {
+		return &usageError{"tag: expect 'add' or 'rm'"}
+	}
+	action := args[0]
+	flags := newFlags("tag " + action)
+	projectId := flags.Int("project", 0, "project id, 0 - personal tag")
+	names, err := parseFlags(flags, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		return &usageError{"tag " + action + ": expect tag names"}
+	}
+
+	for _, name := range names {
+		if action == "add" {
+			err = c.api.CreateTag(*projectId, name)
+		} else {
+			err = c.api.DeleteTag(*projectId, name)
+		}
+		if err != nil {
+			return fmt.Errorf("tag '%s': %w", name, err)
+		}
+	}
+	if action == "add" {
+		return c.out.message(fmt.Sprintf("created %d tag(s)", len(names)), map[string][]string{"created": names})
+	}
+	return c.out.message(fmt.Sprintf("deleted %d tag(s)", len(names)), map[string][]string{"deleted": names})
+}
+
+// exportCommand writes file of tasks matching filters to stdout or to -file
+func exportCommand(c *cli, args []string) error {
+	flags := newFlags("export")
+	filters := taskFilters(flags)
+	params := url.Values{}
+	format := flags.String("format", "markdown", "file format: markdown, csv, ics or todotxt")
+	group := flags.String("group", "", "markdown heading of tasks: tag or due")
+	component := flags.String("component", "", "ics component: todo or event")
+	file := flags.String("file", "", "output file, default stdout")
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) > 0 {
+		return &usageError{"export: unexpected args " + strings.Join(args, " ")}
+	}
+	query, err := filters()
+	if err != nil {
+		return err
+	}
+	params.Set("format", *format)
+	if *group != "" {
+		params.Set("group", *group)
+	}
+	if *component != "" {
+		params.Set("component", *component)
+	}
+
+	data, err := c.api.Export(query, params)
+	if err != nil {
+		return err
+	}
+	if *file == "" {
+		_, err = c.out.w.Write(data)
+		return err
+	}
+	return os.WriteFile(*file, data, 0o644)
+}
+
+// importCommand creates tasks from file, '-' - stdin
+func importCommand(c *cli, args []string) error {
+	flags := newFlags("import")
+	format := flags.String("format", "markdown", "file format: markdown, csv, ics or todotxt")
+	mapping := flags.String("map", "", "column or tag names mapping as from:to,...")
+	createTags := flags.Bool("create-tags", false, "create missing tags")
+	args, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return &usageError{"import: expect one file or '-'"}
+	}
+
+	var file io.Reader = os.Stdin
+	if args[0] != "-" {
+		opened, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer opened.Close()
+		file = opened
+	}
+	params := url.Values{}
+	params.Set("format", *format)
+	if *mapping != "" {
+		params.Set("map", *mapping)
+	}
+	if *createTags {
+		params.Set("create_tags", "true")
+	}
+
+	result, err := c.api.Import(file, params)
+	if err != nil {
+		return err
+	}
+	return c.out.importResult(result)
+}
+
+// parseIds returns task ids from args
+func parseIds(name string, args []string) ([]int, error) {
+	if len(args) == 0 {
+		return nil, &usageError{name + ": expect task ids"}
+	}
+	ids := make([]int, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id <= 0 {
+			return nil, &usageError{fmt.Sprintf("%s: expect task id, given: '%s'", name, arg)}
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseDue returns due in RFC3339 format from date or RFC3339 time, empty value is empty due
+func parseDue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	if due, err := time.Parse(dateFormat, value); err == nil {
+		return due.Format(time.RFC3339), nil
+	}
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		return "", &usageError{fmt.Sprintf("expect due as 2006-01-02 or 2006-01-02T15:04:05Z, given: '%s'", value)}
+	}
+	return value, nil
+}
+
+// splitList returns not empty values separated by ','
+func splitList(value string) []string {
+	var values []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			values = append(values, item)
+		}
+	}
+	return values
+}
@@ -0,0 +1,169 @@
+// todo is command-line client of the API.
+//
+// Usage:
+//
+//	todo [-config path] [-o table|json|plain] <command> [flags] [args]
+//
+// Config file has server url and api key from POST /user/apikey:
+//
+//	server: "http://localhost:8000"
+//	apiKey: "<key>"
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"web/internal/client"
+)
+
+// exit codes of the command
+const (
+	exitOk = iota
+	// exitError unexpected error, e.g. config file is not found
+	exitError
+	// exitUsage wrong command, flags or args
+	exitUsage
+	// exitAuth api key is invalid or has no permission, 401 and 403
+	exitAuth
+	// exitNotFound task or tag is not found, 404
+	exitNotFound
+	// exitInvalid request is rejected by validation, 400, 413 and 415
+	exitInvalid
+	// exitConflict request conflicts with state of the server, 409
+	exitConflict
+	// exitServer server failed, 5xx
+	exitServer
+	// exitConnection server is not reachable
+	exitConnection
+)
+
+// usageError wrong command, flags or args
+type usageError struct {
+	message string
+}
+
+func (e *usageError) Error() string {
+	return e.message
+}
+
+// cli state of the command run
+type cli struct {
+	api *client.Client
+	out *printer
+}
+
+// command of the cli, run gets args after name of the command
+type command struct {
+	usage string
+	run   func(c *cli, args []string) error
+}
+
+var commands = map[string]command{
+	"add":    {"add -tags a,b -due 2006-01-02 [-priority P0-P4] [-project id] [-parent id] [-assignee name] text", addCommand},
+	"ls":     {"ls [-tag a,b] [-mode any|full|short] [-due time] [filters]", lsCommand},
+	"rm":     {"rm id...", rmCommand},
+	"done":   {"done id...", doneCommand},
+	"tags":   {"tags [-project id]", tagsCommand},
+	"tag":    {"tag add|rm [-project id] name...", tagCommand},
+	"export": {"export [-format markdown|csv|ics|todotxt] [-group tag|due] [-file path] [filters]", exportCommand},
+	"import": {"import [-format markdown|csv|ics|todotxt] [-map from:to,...] [-create-tags] file|-", importCommand},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command and returns exit code
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("todo", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { usage(stderr, flags) }
+	configPath := flags.String("config", client.DefaultConfigPath(), "path of config file, env "+client.ConfigEnv)
+	output := flags.String("o", outputTable, "output format: table, json or plain")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 || flags.Arg(0) == "help" {
+		usage(stderr, flags)
+		return exitUsage
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		return exitCode(stderr, &usageError{fmt.Sprintf("unknown command '%s'", flags.Arg(0))})
+	}
+	switch *output {
+	case outputTable, outputJSON, outputPlain:
+	default:
+		return exitCode(stderr, &usageError{fmt.Sprintf("unknown output format '%s'", *output)})
+	}
+
+	cfg, err := client.ReadConfig(*configPath)
+	if err != nil {
+		return exitCode(stderr, err)
+	}
+
+	c := &cli{api: client.New(cfg), out: &printer{w: stdout, format: *output}}
+	return exitCode(stderr, cmd.run(c, flags.Args()[1:]))
+}
+
+// usage prints commands and global flags
+func usage(w io.Writer, flags *flag.FlagSet) {
+	fmt.Fprintln(w, "Usage: todo [-config path] [-o table|json|plain] <command> [flags] [args]")
+	fmt.Fprintln(w, "\nCommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(w, "  todo "+commands[name].usage)
+	}
+	fmt.Fprintln(w, "\nFlags:")
+	flags.PrintDefaults()
+}
+
+// exitCode prints friendly message of the error and returns exit code of the error
+func exitCode(w io.Writer, err error) int {
+	if err == nil {
+		return exitOk
+	}
+
+	var errUsage *usageError
+	if errors.As(err, &errUsage) {
+		fmt.Fprintf(w, "todo: %s, run 'todo help' for usage\n", errUsage.message)
+		return exitUsage
+	}
+
+	var errApi *client.Error
+	if !errors.As(err, &errApi) {
+		fmt.Fprintf(w, "todo: %v\n", err)
+		return exitError
+	}
+
+	code, message := exitError, "request failed"
+	switch status := errApi.Status; {
+	case status == 0:
+		code, message = exitConnection, "server is not reachable"
+	case status == http.StatusUnauthorized:
+		code, message = exitAuth, "not authorized, check apiKey in config"
+	case status == http.StatusForbidden:
+		code, message = exitAuth, "permission denied"
+	case status == http.StatusNotFound:
+		code, message = exitNotFound, "not found"
+	case status == http.StatusBadRequest, status == http.StatusRequestEntityTooLarge,
+		status == http.StatusUnsupportedMediaType:
+		code, message = exitInvalid, "invalid request"
+	case status == http.StatusConflict:
+		code, message = exitConflict, "conflict"
+	case status >= http.StatusInternalServerError:
+		code, message = exitServer, "server error"
+	}
+	fmt.Fprintf(w, "todo: %s: %s\n", message, errApi.Message)
+	return code
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+// output formats
+const (
+	// outputTable aligned columns with header
+	outputTable = "table"
+	// outputJSON json of the API data
+	outputJSON = "json"
+	// outputPlain tab separated columns without header, for scripts
+	outputPlain = "plain"
+)
+
+// printer prints results of commands in output format
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+// task prints task, json is object of the task
+func (p *printer) task(task storage.Task) error {
+	if p.format == outputJSON {
+		return p.json(task)
+	}
+	return p.tasks([]storage.Task{task})
+}
+
+// tasks prints tasks, columns: ID DONE PRIORITY DUE TAGS TEXT
+func (p *printer) tasks(tasks []storage.Task) error {
+	if p.format == outputJSON {
+		return p.json(tasks)
+	}
+	rows := make([][]string, 0, len(tasks))
+	for _, task := range tasks {
+		done := "-"
+		if task.Done {
+			done = "x"
+		}
+		rows = append(rows, []string{strconv.Itoa(task.Id), done, task.Priority, dueText(task.Due),
+			strings.Join(task.Tags, ","), task.Text})
+	}
+	return p.table([]string{"ID", "DONE", "PRIORITY", "DUE", "TAGS", "TEXT"}, rows)
+}
+
+// tags prints tags, columns: ID NAME PROJECT
+func (p *printer) tags(tags []storage.Tag) error {
+	if p.format == outputJSON {
+		return p.json(tags)
+	}
+	rows := make([][]string, 0, len(tags))
+	for _, tag := range tags {
+		rows = append(rows, []string{strconv.Itoa(tag.Id), tag.Name, strconv.Itoa(tag.ProjectId)})
+	}
+	return p.table([]string{"ID", "NAME", "PROJECT"}, rows)
+}
+
+// importResult prints result of each imported record and totals, columns: LINE STATUS TASK ERROR
+func (p *printer) importResult(result *response.ImportResult) error {
+	if p.format == outputJSON {
+		return p.json(result)
+	}
+	rows := make([][]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		task := ""
+		if row.Task != nil {
+			task = strconv.Itoa(row.Task.Id)
+		}
+		rows = append(rows, []string{strconv.Itoa(row.Line), strconv.Itoa(row.Status), task, row.Error})
+	}
+	if err := p.table([]string{"LINE", "STATUS", "TASK", "ERROR"}, rows); err != nil {
+		return err
+	}
+	if p.format == outputTable {
+		_, err := fmt.Fprintf(p.w, "created %d, failed %d\n", result.Created, result.Failed)
+		return err
+	}
+	return nil
+}
+
+// message prints text of the result, value is printed in json format
+func (p *printer) message(text string, value any) error {
+	if p.format == outputJSON {
+		return p.json(value)
+	}
+	_, err := fmt.Fprintln(p.w, text)
+	return err
+}
+
+// table prints rows as aligned columns with header or as tab separated lines in plain format
+func (p *printer) table(header []string, rows [][]string) error {
+	if p.format == outputPlain {
+		for _, row := range rows {
+			if _, err := fmt.Fprintln(p.w, strings.Join(row, "\t")); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	writer := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(writer, strings.Join(row, "\t"))
+	}
+	return writer.Flush()
+}
+
+// json prints value as indented json
+func (p *printer) json(value any) error {
+	encoder := json.NewEncoder(p.w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+// dueText returns due as date if due is midnight UTC, otherwise due as is
+func dueText(due string) string {
+	parsed, err := time.Parse(time.RFC3339, due)
+	if err != nil {
+		return due
+	}
+	parsed = parsed.UTC()
+	if parsed.Equal(parsed.Truncate(24 * time.Hour)) {
+		return parsed.Format(dateFormat)
+	}
+	return due
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// tokenLength is the number of random bytes in a session token
+const tokenLength = 32
+
+// HashPassword returns bcrypt hash of the password
+func HashPassword(password string) (string, error) {
+	const op = "auth.HashPassword"
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("%v: %v", op, err.Error())
+	}
+	return string(hash), nil
+}
+
+// CheckPassword compares password with bcrypt hash, returns true if they match
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewToken generates new random token, returned in hex format
+func NewToken() (string, error) {
+	const op = "auth.NewToken"
+
+	b := make([]byte, tokenLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("%v: %v", op, err.Error())
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HashToken returns sha256 hash of the token.
+// Only hashes are stored in database, so leaked database does not leak tokens.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
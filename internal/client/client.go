@@ -0,0 +1,261 @@
+// Package client is client of the HTTP API for command-line tools
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/server/middleware"
+	"web/internal/storage"
+)
+
+// timeout of one request to the API
+const timeout = 30 * time.Second
+
+// Client sends requests to the API authenticated by api key
+type Client struct {
+	// BaseURL url of the server, e.g. http://localhost:8000
+	BaseURL string
+	ApiKey  string
+	http    *http.Client
+}
+
+// Error response of the API with error status, Status is 0 if server is not reachable
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Status == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%d %s: %s", e.Status, http.StatusText(e.Status), e.Message)
+}
+
+// New returns client of the server from config
+func New(cfg *Config) *Client {
+	return &Client{BaseURL: cfg.Server, ApiKey: cfg.ApiKey, http: &http.Client{Timeout: timeout}}
+}
+
+// TaskQuery filters of task lists, zero value of field means no filter.
+// With Tags tasks are filtered as in GET /task/tag/{mode}/
+type TaskQuery struct {
+	Tags []string
+	// Mode tag mode: empty - tasks with any of tags, full - with all tags, short - with only all tags
+	Mode string
+	// Due exact due, used with Tags
+	Due       string
+	ProjectId int
+	Assignee  string
+	// Priority priorities separated by ','
+	Priority string
+	Sort     string
+	Ready    bool
+	DueFrom  string
+	DueTo    string
+}
+
+// values returns query params of the filters
+func (q *TaskQuery) values() url.Values {
+	values := url.Values{}
+	set := func(name, value string) {
+		if value != "" {
+			values.Set(name, value)
+		}
+	}
+	set("tag", strings.Join(q.Tags, ","))
+	set("due", q.Due)
+	if q.ProjectId != 0 {
+		values.Set("project", strconv.Itoa(q.ProjectId))
+	}
+	set("assignee", q.Assignee)
+	set("priority", q.Priority)
+	set("sort", q.Sort)
+	if q.Ready {
+		values.Set("ready", "true")
+	}
+	set("due_from", q.DueFrom)
+	set("due_to", q.DueTo)
+	return values
+}
+
+// Tasks returns tasks matching query, empty list if there are no matching tasks
+func (c *Client) Tasks(query *TaskQuery) ([]storage.Task, error) {
+	path := "/task/"
+	if len(query.Tags) > 0 {
+		path = "/task/tag/"
+		if query.Mode != "" {
+			path += query.Mode + "/"
+		}
+	}
+
+	var tasks storage.Tasks
+	err := c.do(http.MethodGet, path, query.values(), nil, &tasks)
+	var errApi *Error
+	if errors.As(err, &errApi) && errApi.Status == http.StatusNotFound {
+		return []storage.Task{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tasks.Tasks, nil
+}
+
+// CreateTask creates task
+func (c *Client) CreateTask(task *request.TaskRequest) (*storage.Task, error) {
+	var created storage.Task
+	if err := c.do(http.MethodPost, "/task/", nil, task, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// UpdateTask changes not nil fields of the task
+func (c *Client) UpdateTask(id int, update *request.TaskUpdateRequest) (*storage.Task, error) {
+	var updated storage.Task
+	if err := c.do(http.MethodPatch, fmt.Sprintf("/task/%d", id), nil, update, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteTask moves task to trash
+func (c *Client) DeleteTask(id int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/task/%d", id), nil, nil, nil)
+}
+
+// Tags returns tags of the project, projectId = 0 - personal tags
+func (c *Client) Tags(projectId int) ([]storage.Tag, error) {
+	var tags storage.Tags
+	if err := c.do(http.MethodGet, "/tag/", projectValues(projectId), nil, &tags); err != nil {
+		return nil, err
+	}
+	return tags.Tags, nil
+}
+
+// CreateTag creates tag in the project
+func (c *Client) CreateTag(projectId int, name string) error {
+	return c.do(http.MethodPost, "/tag/", nil, &request.TagRequest{Name: name, ProjectId: projectId}, nil)
+}
+
+// DeleteTag moves tag of the project to trash
+func (c *Client) DeleteTag(projectId int, name string) error {
+	return c.do(http.MethodDelete, "/tag/"+url.PathEscape(name), projectValues(projectId), nil, nil)
+}
+
+// Export returns file of tasks matching query, params - params of the format: format, group, component
+func (c *Client) Export(query *TaskQuery, params url.Values) ([]byte, error) {
+	values := query.values()
+	for name := range params {
+		values.Set(name, params.Get(name))
+	}
+	if query.Mode != "" {
+		values.Set("mode", query.Mode)
+	}
+
+	resp, err := c.send(http.MethodGet, "/task/export", values, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// Import creates tasks from file, params - params of the import: format, map, create_tags
+func (c *Client) Import(file io.Reader, params url.Values) (*response.ImportResult, error) {
+	resp, err := c.send(http.MethodPost, "/task/import", params, "text/plain", file)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result response.ImportResult
+	if err = decode(resp, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// projectValues returns project query param, no params for projectId = 0
+func projectValues(projectId int) url.Values {
+	values := url.Values{}
+	if projectId != 0 {
+		values.Set("project", strconv.Itoa(projectId))
+	}
+	return values
+}
+
+// do sends request with json body and decodes data of response to result, result = nil - data is skipped
+func (c *Client) do(method, path string, query url.Values, body any, result any) error {
+	var content io.Reader
+	contentType := ""
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		content, contentType = bytes.NewReader(data), "application/json"
+	}
+
+	resp, err := c.send(method, path, query, contentType, content)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decode(resp, result)
+}
+
+// send sends request, response with error status is returned as *Error
+func (c *Client) send(method, path string, query url.Values, contentType string, body io.Reader) (*http.Response, error) {
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set(middleware.ApiKeyHeader, c.ApiKey)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, &Error{Message: fmt.Sprintf("can't connect to server %s: %v", c.BaseURL, err)}
+	}
+	if resp.StatusCode < http.StatusBadRequest {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	var errorResponse response.ErrorResponse
+	if err = json.NewDecoder(resp.Body).Decode(&errorResponse); err != nil || errorResponse.Error == "" {
+		errorResponse.Error = http.StatusText(resp.StatusCode)
+	}
+	return nil, &Error{Status: resp.StatusCode, Message: errorResponse.Error}
+}
+
+// decode decodes data of ok response to result, result = nil - data is skipped
+func decode(resp *http.Response, result any) error {
+	if result == nil {
+		return nil
+	}
+	data := struct {
+		Data any `json:"data"`
+	}{Data: result}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return fmt.Errorf("can't read response of server: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigEnv environment variable with path of config file, it replaces default path
+const ConfigEnv = "TODO_CONFIG"
+
+// Config of command-line tools, e.g.
+//
+//	server: "http://localhost:8000"
+//	apiKey: "key from POST /user/apikey"
+type Config struct {
+	Server string `yaml:"server"`
+	ApiKey string `yaml:"apiKey"`
+}
+
+// DefaultConfigPath returns path of config file from ConfigEnv, or todo/config.yaml in user config directory
+func DefaultConfigPath() string {
+	if path := os.Getenv(ConfigEnv); path != "" {
+		return path
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join("config", "todo.yaml")
+	}
+	return filepath.Join(dir, "todo", "config.yaml")
+}
+
+// ReadConfig reads config file, server and api key are required
+func ReadConfig(path string) (*Config, error) {
+	const op = "client.ReadConfig"
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", op, err.Error())
+	}
+	defer file.Close()
+
+	cfg := &Config{}
+	if err = yaml.NewDecoder(file).Decode(cfg); err != nil {
+		return nil, fmt.Errorf("%v: %v", op, err.Error())
+	}
+	cfg.Server = strings.TrimSuffix(cfg.Server, "/")
+	if cfg.Server == "" || cfg.ApiKey == "" {
+		return nil, fmt.Errorf("%v: expect server and apiKey in %s", op, path)
+	}
+	return cfg, nil
+}
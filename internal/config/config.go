@@ -5,16 +5,34 @@ import (
 	"gopkg.in/yaml.v3"
 	"log/slog"
 	"os"
+	"time"
 )
 
 type Config struct {
 	Server         `yaml:"server"`
 	DatabaseConfig `yaml:"databaseConfig"`
+	Auth           `yaml:"auth"`
+	BlobStore      `yaml:"blobStore"`
+	Attachments    `yaml:"attachments"`
+	Trash          `yaml:"trash"`
+	Undo           `yaml:"undo"`
+	Admin          `yaml:"admin"`
 }
 
+// Server http server, timeouts which are not set get defaults of the server
 type Server struct {
 	Host string `yaml:"host"`
 	Port string `yaml:"port"`
+	// ReadHeaderTimeout max time to read request headers
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+	// ReadTimeout max time to read whole request with body
+	ReadTimeout time.Duration `yaml:"readTimeout"`
+	// WriteTimeout max time from the end of request headers to the end of response
+	WriteTimeout time.Duration `yaml:"writeTimeout"`
+	// IdleTimeout max time keep-alive connection waits for next request
+	IdleTimeout time.Duration `yaml:"idleTimeout"`
+	// ShutdownTimeout max time in-flight requests are finished on shutdown, then they are closed
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
 }
 
 type DatabaseConfig struct {
@@ -22,6 +40,41 @@ type DatabaseConfig struct {
 	Config map[string]string `yaml:"config"`
 }
 
+type Auth struct {
+	// SessionTTL lifetime of session token issued on login
+	SessionTTL time.Duration `yaml:"sessionTTL"`
+}
+
+// BlobStore storage of attachment files, config depends on type, local - path: directory of files
+type BlobStore struct {
+	Type   string            `yaml:"type"`
+	Config map[string]string `yaml:"config"`
+}
+
+type Attachments struct {
+	// MaxSize max size of attachment file in bytes
+	MaxSize int64 `yaml:"maxSize"`
+	// AllowedTypes MIME types of attachment files detected by content, type/* allows all subtypes
+	AllowedTypes []string `yaml:"allowedTypes"`
+}
+
+type Trash struct {
+	// Retention time trashed tasks and tags are kept before auto-purge, 0 - auto-purge is disabled
+	Retention time.Duration `yaml:"retention"`
+	// PurgeInterval how often old trash is purged
+	PurgeInterval time.Duration `yaml:"purgeInterval"`
+}
+
+type Undo struct {
+	// Window time after operation during which it can be undone
+	Window time.Duration `yaml:"window"`
+}
+
+type Admin struct {
+	// MaxRestoreSize max size of database snapshot in body of restore request in bytes
+	MaxRestoreSize int64 `yaml:"maxRestoreSize"`
+}
+
 // NewConfig read and create Config for project
 func NewConfig(configFilePath string, log *slog.Logger) *Config {
 	//validate configFilePath
@@ -0,0 +1,193 @@
+package format
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"web/internal/storage"
+)
+
+// CSV columns, exported file has all columns in this order
+const (
+	ColumnId        = "id"
+	ColumnText      = "text"
+	ColumnTags      = "tags"
+	ColumnDue       = "due"
+	ColumnPriority  = "priority"
+	ColumnProjectId = "project_id"
+	ColumnAssignee  = "assignee"
+	ColumnParentId  = "parent_id"
+	ColumnDone      = "done"
+)
+
+// CSVColumns columns of exported file
+var CSVColumns = []string{ColumnId, ColumnText, ColumnTags, ColumnDue, ColumnPriority, ColumnProjectId,
+	ColumnAssignee, ColumnParentId, ColumnDone}
+
+// csvTagsSeparator separates tags in tags column
+const csvTagsSeparator = ";"
+
+// WriteCSV writes tasks with header, tags are separated by '; ', zero ids are empty
+func WriteCSV(w io.Writer, tasks []storage.Task) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(CSVColumns); err != nil {
+		return err
+	}
+
+	for _, task := range tasks {
+		err := writer.Write([]string{
+			strconv.Itoa(task.Id),
+			task.Text,
+			strings.Join(task.Tags, csvTagsSeparator+" "),
+			task.Due,
+			task.Priority,
+			csvId(task.ProjectId),
+			task.Assignee,
+			csvId(task.ParentId),
+			strconv.FormatBool(task.Done),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvId returns id as text, empty for 0
+func csvId(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
+
+// ReadCSV reads tasks from CSV with header. Columns are found by names of header cells, case is ignored,
+// mapping - column of the file to column of CSVColumns for header cells with other names.
+// Columns with unknown names are skipped, id column is ignored, text, tags and due columns are required.
+// Error is returned if file is not valid CSV, error of the record is in its Row
+func ReadCSV(r io.Reader, mapping map[string]string) ([]Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("expect header of CSV file")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := csvHeader(header, mapping)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		row := Row{Line: line}
+		row.Task, row.Err = csvTask(record, columns)
+		rows = append(rows, row)
+	}
+}
+
+// csvHeader returns index of each known column in records
+func csvHeader(header []string, mapping map[string]string) (map[string]int, error) {
+	known := make(map[string]bool, len(CSVColumns))
+	for _, column := range CSVColumns {
+		known[column] = true
+	}
+	names := make(map[string]string, len(mapping))
+	for from, to := range mapping {
+		to = strings.ToLower(strings.TrimSpace(to))
+		if !known[to] {
+			return nil, fmt.Errorf("unknown column '%s' in mapping, expect one of: %s", to, strings.Join(CSVColumns, ", "))
+		}
+		names[strings.ToLower(strings.TrimSpace(from))] = to
+	}
+
+	columns := make(map[string]int)
+	for i, cell := range header {
+		name := strings.ToLower(strings.TrimSpace(cell))
+		if mapped, ok := names[name]; ok {
+			name = mapped
+		}
+		if !known[name] {
+			continue
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("duplicate column '%s'", name)
+		}
+		columns[name] = i
+	}
+
+	for _, required := range []string{ColumnText, ColumnTags, ColumnDue} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("expect column '%s' in header", required)
+		}
+	}
+	return columns, nil
+}
+
+// csvTask returns task from the record, missing cells are empty
+func csvTask(record []string, columns map[string]int) (storage.Task, error) {
+	cell := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	task := storage.Task{
+		Text:     cell(ColumnText),
+		Due:      cell(ColumnDue),
+		Priority: cell(ColumnPriority),
+		Assignee: cell(ColumnAssignee),
+	}
+	for _, tag := range strings.Split(cell(ColumnTags), csvTagsSeparator) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			task.Tags = append(task.Tags, tag)
+		}
+	}
+
+	var err error
+	if task.ProjectId, err = csvIdCell(ColumnProjectId, cell(ColumnProjectId)); err != nil {
+		return task, err
+	}
+	if task.ParentId, err = csvIdCell(ColumnParentId, cell(ColumnParentId)); err != nil {
+		return task, err
+	}
+	if done := cell(ColumnDone); done != "" {
+		if task.Done, err = strconv.ParseBool(done); err != nil {
+			return task, fmt.Errorf("expect %s as true or false, given: '%s'", ColumnDone, done)
+		}
+	}
+	return task, nil
+}
+
+// csvIdCell returns id from the cell of the column, 0 for empty cell
+func csvIdCell(column, text string) (int, error) {
+	if text == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(text)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("expect %s as positive integer, given: '%s'", column, text)
+	}
+	return id, nil
+}
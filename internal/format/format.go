@@ -0,0 +1,12 @@
+// Package format converts tasks to and from files of other tools: spreadsheets, calendars, todo lists
+package format
+
+import "web/internal/storage"
+
+// Row task read from one record of imported file.
+// Line - line of the record in the file starting from 1, Err - error of reading the record, Task is not valid then
+type Row struct {
+	Line int
+	Task storage.Task
+	Err  error
+}
@@ -0,0 +1,302 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+	"web/internal/storage"
+)
+
+// iCalendar components of exported tasks
+const (
+	// ICSTodo task is VTODO with DUE, shown in task lists of calendar apps
+	ICSTodo = "VTODO"
+	// ICSEvent task is VEVENT starting at due, for calendar apps without tasks
+	ICSEvent = "VEVENT"
+)
+
+// icsTimeFormat UTC date-time of iCalendar
+const icsTimeFormat = "20060102T150405Z"
+
+// icsLineLength max length of line in octets, longer lines are folded
+const icsLineLength = 75
+
+// icsPriorities iCalendar priority 1-9 of P0-P4, 0 - priority is not defined
+var icsPriorities = []int{1, 3, 5, 7, 9}
+
+// WriteICS writes tasks as iCalendar components, component - ICSTodo or ICSEvent.
+// Tags are categories, tasks without valid due are skipped
+func WriteICS(w io.Writer, tasks []storage.Task, component string) error {
+	writer := bufio.NewWriter(w)
+	line := func(name, value string) {
+		writeICSLine(writer, name+":"+value)
+	}
+
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//web//tasks//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("X-WR-CALNAME", "Tasks")
+	stamp := time.Now().UTC().Format(icsTimeFormat)
+	for _, task := range tasks {
+		due, err := time.Parse(time.RFC3339, task.Due)
+		if err != nil {
+			continue
+		}
+
+		line("BEGIN", component)
+		line("UID", fmt.Sprintf("task-%d@web", task.Id))
+		line("DTSTAMP", stamp)
+		line("SUMMARY", icsEscape(task.Text))
+		if component == ICSEvent {
+			line("DTSTART", due.UTC().Format(icsTimeFormat))
+		} else {
+			line("DUE", due.UTC().Format(icsTimeFormat))
+			if task.Done {
+				line("STATUS", "COMPLETED")
+			} else {
+				line("STATUS", "NEEDS-ACTION")
+			}
+		}
+		var categories []string
+		for _, tag := range task.Tags {
+			if tag != "" {
+				categories = append(categories, icsEscape(tag))
+			}
+		}
+		if len(categories) > 0 {
+			line("CATEGORIES", strings.Join(categories, ","))
+		}
+		if priority, err := storage.ParsePriority(task.Priority); err == nil {
+			line("PRIORITY", strconv.Itoa(icsPriorities[priority]))
+		}
+		line("END", component)
+	}
+	line("END", "VCALENDAR")
+
+	return writer.Flush()
+}
+
+// writeICSLine writes line with CRLF, folds line longer than icsLineLength octets without splitting characters
+func writeICSLine(w *bufio.Writer, line string) {
+	limit := icsLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		_, _ = w.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// folded line starts with space
+		limit = icsLineLength - 1
+	}
+	_, _ = w.WriteString(line + "\r\n")
+}
+
+// icsEscape escapes text value
+func icsEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(text)
+}
+
+// icsUnescape returns text value without escaping
+func icsUnescape(text string) string {
+	return strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n").Replace(text)
+}
+
+// icsSplit splits list value by not escaped commas
+func icsSplit(value string) []string {
+	var values []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			values = append(values, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(values, value[start:])
+}
+
+// icsLine content line of iCalendar: NAME;PARAM=VALUE:value
+type icsLine struct {
+	number int
+	name   string
+	params map[string]string
+	value  string
+}
+
+// ReadICS reads VTODO components as tasks, other components are skipped.
+// SUMMARY is text, CATEGORIES are tags, DUE is due, PRIORITY is priority and STATUS:COMPLETED marks task done.
+// mapping - category to tag for categories with other names, case is ignored.
+// Error is returned if file is not iCalendar, error of the component is in its Row
+func ReadICS(r io.Reader, mapping map[string]string) ([]Row, error) {
+	tags := make(map[string]string, len(mapping))
+	for from, to := range mapping {
+		tags[strings.ToLower(strings.TrimSpace(from))] = strings.TrimSpace(to)
+	}
+
+	lines, err := icsLines(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || lines[0].name != "BEGIN" || !strings.EqualFold(lines[0].value, "VCALENDAR") {
+		return nil, fmt.Errorf("expect iCalendar file starting with BEGIN:VCALENDAR")
+	}
+
+	var rows []Row
+	var row *Row
+	// depth of components nested in VTODO, e.g. VALARM
+	depth := 0
+	for _, line := range lines {
+		switch {
+		case line.name == "BEGIN" && row == nil && strings.EqualFold(line.value, ICSTodo):
+			row = &Row{Line: line.number}
+		case row == nil:
+		case line.name == "BEGIN":
+			depth++
+		case line.name == "END" && depth > 0:
+			depth--
+		case line.name == "END":
+			rows = append(rows, *row)
+			row = nil
+		case depth > 0 || row.Err != nil:
+		default:
+			row.Err = icsProperty(&row.Task, line, tags)
+		}
+	}
+	if row != nil {
+		return nil, fmt.Errorf("expect END:%s of component at line %d", ICSTodo, row.Line)
+	}
+	return rows, nil
+}
+
+// icsProperty sets field of the task by property of VTODO
+func icsProperty(task *storage.Task, line icsLine, tags map[string]string) error {
+	switch line.name {
+	case "SUMMARY":
+		task.Text = strings.TrimSpace(icsUnescape(line.value))
+	case "CATEGORIES":
+		for _, category := range icsSplit(line.value) {
+			category = strings.TrimSpace(icsUnescape(category))
+			if tag, ok := tags[strings.ToLower(category)]; ok {
+				category = tag
+			}
+			if category != "" {
+				task.Tags = append(task.Tags, category)
+			}
+		}
+	case "DUE":
+		due, err := icsTime(line)
+		if err != nil {
+			return err
+		}
+		task.Due = due.UTC().Format(time.RFC3339)
+	case "PRIORITY":
+		priority, err := strconv.Atoi(line.value)
+		if err != nil || priority < 0 || priority > 9 {
+			return fmt.Errorf("expect PRIORITY from 0 to 9, given: '%s'", line.value)
+		}
+		// 0 - priority is not defined, task gets default priority
+		if priority > 0 {
+			task.Priority = storage.PriorityName((priority - 1) / 2)
+		}
+	case "STATUS":
+		task.Done = strings.EqualFold(line.value, "COMPLETED")
+	}
+	return nil
+}
+
+// icsTime returns time of date or date-time property, time without zone is UTC
+func icsTime(line icsLine) (time.Time, error) {
+	value := line.value
+	if strings.EqualFold(line.params["VALUE"], "DATE") || len(value) == len("20060102") {
+		date, err := time.Parse("20060102", value)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("expect %s date as 20060102, given: '%s'", line.name, value)
+		}
+		return date, nil
+	}
+
+	location := time.UTC
+	if zone := line.params["TZID"]; zone != "" && !strings.HasSuffix(value, "Z") {
+		var err error
+		if location, err = time.LoadLocation(zone); err != nil {
+			return time.Time{}, fmt.Errorf("unknown %s time zone '%s'", line.name, zone)
+		}
+	}
+	date, err := time.ParseInLocation("20060102T150405", strings.TrimSuffix(value, "Z"), location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expect %s as 20060102T150405Z, given: '%s'", line.name, value)
+	}
+	return date, nil
+}
+
+// icsLines returns unfolded content lines, names and param names are in upper case
+func icsLines(r io.Reader) ([]icsLine, error) {
+	scanner := bufio.NewScanner(r)
+	var lines []icsLine
+	number := 0
+	for scanner.Scan() {
+		number++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		if text == "" {
+			continue
+		}
+		// folded line continues previous line
+		if (text[0] == ' ' || text[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1].value += text[1:]
+			continue
+		}
+		lines = append(lines, icsLine{number: number, value: text})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range lines {
+		line, err := icsParseLine(lines[i].value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lines[i].number, err)
+		}
+		line.number = lines[i].number
+		lines[i] = line
+	}
+	return lines, nil
+}
+
+// icsParseLine splits unfolded content line to name, params and value
+func icsParseLine(text string) (icsLine, error) {
+	line := icsLine{params: make(map[string]string)}
+
+	// value starts after the first colon outside of quoted param value
+	quoted := false
+	colon := -1
+	for i := 0; i < len(text) && colon < 0; i++ {
+		switch text[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 0 {
+		return line, fmt.Errorf("expect 'name:value', given: '%s'", text)
+	}
+	line.value = text[colon+1:]
+
+	parts := strings.Split(text[:colon], ";")
+	line.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		line.params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+	return line, nil
+}
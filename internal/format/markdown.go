@@ -0,0 +1,163 @@
+package format
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"web/internal/storage"
+)
+
+// markdown checklist: heading of each group and task per line
+// '- [ ] text #tag #other (due 2024-05-01)', '- [x]' - task is done.
+// Due without time is '(due 2024-05-01)', due with time is '(due 2024-05-01T10:00:00Z)'.
+// Tags and due are read only from the end of the item, '#word' and '(due ...)' followed by text are text
+
+// groups of tasks in markdown checklist
+const (
+	// GroupTag tasks are grouped by their first tag
+	GroupTag = "tag"
+	// GroupDue tasks are grouped by due date in UTC
+	GroupDue = "due"
+)
+
+// markdownNoGroup heading of tasks without tag or due
+const markdownNoGroup = "other"
+
+// markdownItem checklist item: marker, check and text of the task
+var markdownItem = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])]\s+(.*)$`)
+
+// markdownDue due of the task at the end of item text
+var markdownDue = regexp.MustCompile(`(?:^|\s)\(due\s+([^)\s]+)\)$`)
+
+// markdownTag tag of the task at the end of item text
+var markdownTag = regexp.MustCompile(`(?:^|\s)#(\S+)$`)
+
+// MarkdownLine returns task as item of markdown checklist
+func MarkdownLine(task storage.Task) string {
+	check := " "
+	if task.Done {
+		check = "x"
+	}
+	words := []string{"-", "[" + check + "]"}
+	words = append(words, strings.Fields(task.Text)...)
+	for _, tag := range task.Tags {
+		if tag != "" {
+			words = append(words, "#"+tag)
+		}
+	}
+	if due, err := time.Parse(time.RFC3339, task.Due); err == nil {
+		due = due.UTC()
+		if due.Equal(due.Truncate(24 * time.Hour)) {
+			words = append(words, "(due "+due.Format(todoTxtDate)+")")
+		} else {
+			words = append(words, "(due "+due.Format(time.RFC3339)+")")
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// WriteMarkdown writes tasks as markdown checklist with heading of each group, group - GroupTag or GroupDue.
+// Groups are sorted by name, tasks keep their order in the group
+func WriteMarkdown(w io.Writer, tasks []storage.Task, group string) error {
+	groups := make(map[string][]storage.Task)
+	for _, task := range tasks {
+		name := markdownGroup(task, group)
+		groups[name] = append(groups[name], task)
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	writer := bufio.NewWriter(w)
+	for i, name := range names {
+		if i > 0 {
+			_, _ = writer.WriteString("\n")
+		}
+		_, _ = writer.WriteString("## " + name + "\n\n")
+		for _, task := range groups[name] {
+			_, _ = writer.WriteString(MarkdownLine(task) + "\n")
+		}
+	}
+	return writer.Flush()
+}
+
+// markdownGroup returns name of the task group
+func markdownGroup(task storage.Task, group string) string {
+	if group == GroupDue {
+		due, err := time.Parse(time.RFC3339, task.Due)
+		if err != nil {
+			return markdownNoGroup
+		}
+		return due.UTC().Format(todoTxtDate)
+	}
+	for _, tag := range task.Tags {
+		if tag != "" {
+			return tag
+		}
+	}
+	return markdownNoGroup
+}
+
+// ParseMarkdownLine returns task from checklist item text after '- [ ] ', mapping - #tag to tag with other name,
+// case is ignored
+func ParseMarkdownLine(text string, done bool, mapping map[string]string) (storage.Task, error) {
+	task := storage.Task{Done: done}
+	tags := make(map[string]string, len(mapping))
+	for from, to := range mapping {
+		tags[strings.ToLower(strings.TrimSpace(from))] = strings.TrimSpace(to)
+	}
+
+	// due and tags are read from the end of the item, so '(due ...)' and '#word' inside text stay in text
+	text = strings.TrimSpace(text)
+	for {
+		if match := markdownDue.FindStringSubmatchIndex(text); match != nil && task.Due == "" {
+			due, err := todoTxtDue(text[match[2]:match[3]])
+			if err != nil {
+				return task, err
+			}
+			task.Due = due
+			text = strings.TrimSpace(text[:match[0]])
+			continue
+		}
+		if match := markdownTag.FindStringSubmatchIndex(text); match != nil {
+			tag := text[match[2]:match[3]]
+			if mapped, ok := tags[strings.ToLower(tag)]; ok {
+				tag = mapped
+			}
+			task.Tags = append([]string{tag}, task.Tags...)
+			text = strings.TrimSpace(text[:match[0]])
+			continue
+		}
+		break
+	}
+	task.Text = strings.Join(strings.Fields(text), " ")
+	return task, nil
+}
+
+// ReadMarkdown reads tasks from checklist items '- [ ] text', other lines are skipped.
+// mapping - #tag to tag with other name, case is ignored
+func ReadMarkdown(r io.Reader, mapping map[string]string) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	var rows []Row
+	number := 0
+	for scanner.Scan() {
+		number++
+		match := markdownItem.FindStringSubmatch(scanner.Text())
+		if match == nil {
+			continue
+		}
+
+		row := Row{Line: number}
+		row.Task, row.Err = ParseMarkdownLine(match[2], match[1] != " ", mapping)
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
@@ -0,0 +1,99 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+	"web/internal/storage"
+)
+
+func TestMarkdownRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		task storage.Task
+	}{
+		{
+			name: "due date",
+			task: storage.Task{Text: "buy milk", Tags: []string{"home"}, Due: "2030-06-02T00:00:00Z"},
+		},
+		{
+			name: "due time",
+			task: storage.Task{Text: "call bob", Tags: []string{"work", "phone"}, Due: "2030-06-02T10:30:00Z"},
+		},
+		{
+			name: "done",
+			task: storage.Task{Text: "send report", Tags: []string{"work"}, Due: "2030-06-02T00:00:00Z", Done: true},
+		},
+		{
+			name: "tag and due in text",
+			task: storage.Task{Text: "fix issue #42 (due soon)", Tags: []string{"work"}, Due: "2030-06-02T00:00:00Z"},
+		},
+		{
+			name: "due date in text",
+			task: storage.Task{Text: "move (due 2030-01-01) to next week", Tags: []string{"work"}, Due: "2030-06-02T00:00:00Z"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var file bytes.Buffer
+			if err := WriteMarkdown(&file, []storage.Task{test.task}, GroupTag); err != nil {
+				t.Fatalf("WriteMarkdown: %v", err)
+			}
+			rows, err := ReadMarkdown(&file, nil)
+			if err != nil {
+				t.Fatalf("ReadMarkdown: %v", err)
+			}
+			line := MarkdownLine(test.task)
+			if len(rows) != 1 {
+				t.Fatalf("line %q: expect 1 row, got: %d", line, len(rows))
+			}
+			if rows[0].Err != nil {
+				t.Fatalf("line %q: %v", line, rows[0].Err)
+			}
+			assertTask(t, line, rows[0].Task, test.task)
+		})
+	}
+}
+
+func TestParseMarkdownLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		mapping map[string]string
+		want    storage.Task
+	}{
+		{
+			name: "due before tags",
+			text: "water plants (due 2030-06-02T10:00:00+02:00) #home #garden",
+			want: storage.Task{Text: "water plants", Tags: []string{"home", "garden"}, Due: "2030-06-02T08:00:00Z"},
+		},
+		{
+			name: "second due is text",
+			text: "pay rent (due 2030-05-01) (due 2030-06-02)",
+			want: storage.Task{Text: "pay rent (due 2030-05-01)", Due: "2030-06-02T00:00:00Z"},
+		},
+		{
+			name:    "mapping",
+			text:    "plan sprint #Business",
+			mapping: map[string]string{"business": "work"},
+			want:    storage.Task{Text: "plan sprint", Tags: []string{"work"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			task, err := ParseMarkdownLine(test.text, false, test.mapping)
+			if err != nil {
+				t.Fatalf("ParseMarkdownLine(%q): %v", test.text, err)
+			}
+			assertTask(t, test.text, task, test.want)
+		})
+	}
+}
+
+func TestParseMarkdownLineError(t *testing.T) {
+	text := "pay rent #home (due tomorrow)"
+	if _, err := ParseMarkdownLine(text, false, nil); err == nil {
+		t.Errorf("ParseMarkdownLine(%q): expect error", text)
+	}
+}
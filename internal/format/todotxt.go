@@ -0,0 +1,201 @@
+package format
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"web/internal/storage"
+)
+
+// todo.txt format: one task per line
+// 'x 2024-05-02 (A) 2024-05-01 text +project @context due:2024-05-10'
+// x - task is done, then completion date, (A)-(Z) - priority, then creation date, key:value - extensions.
+// Tags are +project on export, both +project and @context are tags on import. Tags and extensions are
+// read only from the end of the line, '+word' and '@word' followed by text are text.
+// Priority letters A-E are P0-P4, later letters are P4.
+// Due without time is 'due:2024-05-10', due with time is 'due:2024-05-10T10:00:00Z'.
+// Done task has priority in 'pri:A' extension, as done task line starts with 'x'
+
+// todoTxtDate date format of todo.txt
+const todoTxtDate = "2006-01-02"
+
+// todoTxtLowestPriority priority of letters after E, P4
+const todoTxtLowestPriority = 4
+
+// TodoTxtLine returns task as todo.txt line
+func TodoTxtLine(task storage.Task) string {
+	var words []string
+	priority, priorityErr := storage.ParsePriority(task.Priority)
+	switch {
+	case task.Done:
+		words = append(words, "x")
+	case priorityErr == nil:
+		words = append(words, fmt.Sprintf("(%c)", 'A'+priority))
+	}
+
+	words = append(words, strings.Fields(task.Text)...)
+	for _, tag := range task.Tags {
+		if tag != "" {
+			words = append(words, "+"+tag)
+		}
+	}
+	if due, err := time.Parse(time.RFC3339, task.Due); err == nil {
+		due = due.UTC()
+		if due.Equal(due.Truncate(24 * time.Hour)) {
+			words = append(words, "due:"+due.Format(todoTxtDate))
+		} else {
+			words = append(words, "due:"+due.Format(time.RFC3339))
+		}
+	}
+	if task.Done && priorityErr == nil {
+		words = append(words, fmt.Sprintf("pri:%c", 'A'+priority))
+	}
+	return strings.Join(words, " ")
+}
+
+// ParseTodoTxtLine returns task from todo.txt line, mapping - +project or @context name to tag with other name,
+// case is ignored. Unknown extensions at the end of the line are kept at the end of text,
+// dates of completion and creation are skipped
+func ParseTodoTxtLine(line string, mapping map[string]string) (storage.Task, error) {
+	var task storage.Task
+	tags := make(map[string]string, len(mapping))
+	for from, to := range mapping {
+		tags[strings.ToLower(strings.TrimSpace(from))] = strings.TrimSpace(to)
+	}
+
+	words := strings.Fields(line)
+
+	if len(words) > 0 && words[0] == "x" {
+		task.Done = true
+		words = words[1:]
+		// completion date
+		if len(words) > 0 && isTodoTxtDate(words[0]) {
+			words = words[1:]
+		}
+	}
+	// priority is upper case letter, '(a)' is text
+	if len(words) > 0 && len(words[0]) == 3 && words[0][0] == '(' && words[0][2] == ')' &&
+		words[0][1] >= 'A' && words[0][1] <= 'Z' {
+		priority, err := todoTxtPriority(words[0][1:2])
+		if err != nil {
+			return task, err
+		}
+		task.Priority = priority
+		words = words[1:]
+	}
+	// creation date
+	if len(words) > 0 && isTodoTxtDate(words[0]) {
+		words = words[1:]
+	}
+
+	// tags and extensions are read from the end of the line, so '+word' and '@word' inside text stay in text
+	end := len(words)
+	var extensions []string
+	for ; end > 0; end-- {
+		word := words[end-1]
+		key, value, extension := strings.Cut(word, ":")
+		switch {
+		case isTodoTxtTag(word):
+			tag := word[1:]
+			if mapped, ok := tags[strings.ToLower(tag)]; ok {
+				tag = mapped
+			}
+			task.Tags = append([]string{tag}, task.Tags...)
+			continue
+		case extension && key == "due" && task.Due == "":
+			due, err := todoTxtDue(value)
+			if err != nil {
+				return task, err
+			}
+			task.Due = due
+			continue
+		// priority of done task, it has no '(A)'
+		case extension && key == "pri" && task.Priority == "":
+			priority, err := todoTxtPriority(value)
+			if err != nil {
+				return task, err
+			}
+			task.Priority = priority
+			continue
+		case extension && key != "" && value != "" && key != "due" && key != "pri":
+			// unknown extension
+			extensions = append([]string{word}, extensions...)
+			continue
+		}
+		break
+	}
+	task.Text = strings.Join(append(words[:end:end], extensions...), " ")
+	return task, nil
+}
+
+// WriteTodoTxt writes tasks as todo.txt lines
+func WriteTodoTxt(w io.Writer, tasks []storage.Task) error {
+	writer := bufio.NewWriter(w)
+	for _, task := range tasks {
+		if _, err := writer.WriteString(TodoTxtLine(task) + "\n"); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// ReadTodoTxt reads tasks from todo.txt lines, empty lines are skipped.
+// mapping - +project or @context name to tag with other name, case is ignored
+func ReadTodoTxt(r io.Reader, mapping map[string]string) ([]Row, error) {
+	scanner := bufio.NewScanner(r)
+	var rows []Row
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		row := Row{Line: number}
+		row.Task, row.Err = ParseTodoTxtLine(line, mapping)
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// isTodoTxtTag reports whether word is +project or @context
+func isTodoTxtTag(word string) bool {
+	return len(word) > 1 && (word[0] == '+' || word[0] == '@')
+}
+
+// isTodoTxtDate reports whether word is date of todo.txt
+func isTodoTxtDate(word string) bool {
+	_, err := time.Parse(todoTxtDate, word)
+	return err == nil
+}
+
+// todoTxtPriority returns priority name of priority letter
+func todoTxtPriority(letter string) (string, error) {
+	if len(letter) != 1 || letter[0] < 'A' || letter[0] > 'Z' {
+		return "", fmt.Errorf("expect priority letter A-Z, given: '%s'", letter)
+	}
+	// letters after E are the lowest priority P4
+	priority := storage.PriorityName(int(letter[0] - 'A'))
+	if priority == "" {
+		priority = storage.PriorityName(todoTxtLowestPriority)
+	}
+	return priority, nil
+}
+
+// todoTxtDue returns due in RFC3339 format from date or RFC3339 time
+func todoTxtDue(value string) (string, error) {
+	if due, err := time.Parse(todoTxtDate, value); err == nil {
+		return due.Format(time.RFC3339), nil
+	}
+	due, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return "", fmt.Errorf("expect due as 2006-01-02 or 2006-01-02T15:04:05Z, given: '%s'", value)
+	}
+	return due.UTC().Format(time.RFC3339), nil
+}
@@ -0,0 +1,108 @@
+package format
+
+import (
+	"slices"
+	"testing"
+	"web/internal/storage"
+)
+
+func TestTodoTxtRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		task storage.Task
+	}{
+		{
+			name: "due date",
+			task: storage.Task{Text: "buy milk", Tags: []string{"home"}, Due: "2030-06-02T00:00:00Z", Priority: "P2"},
+		},
+		{
+			name: "due time",
+			task: storage.Task{Text: "call bob", Tags: []string{"work", "phone"}, Due: "2030-06-02T10:30:00Z", Priority: "P0"},
+		},
+		{
+			name: "done",
+			task: storage.Task{Text: "send report", Tags: []string{"work"}, Due: "2030-06-02T00:00:00Z", Priority: "P4", Done: true},
+		},
+		{
+			name: "tags in text",
+			task: storage.Task{Text: "vote +1 on @alice proposal", Tags: []string{"work"}, Due: "2030-06-02T00:00:00Z", Priority: "P1"},
+		},
+		{
+			name: "extensions in text",
+			task: storage.Task{Text: "set due:soon and pri:high at 10:30", Tags: []string{"home"}, Due: "2030-06-02T00:00:00Z", Priority: "P3"},
+		},
+		{
+			name: "extensions in text of done task",
+			task: storage.Task{Text: "set pri:high", Tags: []string{"home"}, Due: "2030-06-02T00:00:00Z", Priority: "P3", Done: true},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			line := TodoTxtLine(test.task)
+			task, err := ParseTodoTxtLine(line, nil)
+			if err != nil {
+				t.Fatalf("ParseTodoTxtLine(%q): %v", line, err)
+			}
+			assertTask(t, line, task, test.task)
+		})
+	}
+}
+
+func TestParseTodoTxtLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		mapping map[string]string
+		want    storage.Task
+	}{
+		{
+			name: "dates and context",
+			line: "x 2024-05-02 2024-05-01 call mom +family @phone due:2030-06-02 pri:B",
+			want: storage.Task{Text: "call mom", Tags: []string{"family", "phone"}, Due: "2030-06-02T00:00:00Z", Priority: "P1", Done: true},
+		},
+		{
+			name: "priority after E",
+			line: "(Z) 2024-05-01 read book +home",
+			want: storage.Task{Text: "read book", Tags: []string{"home"}, Priority: "P4"},
+		},
+		{
+			name: "unknown extension",
+			line: "water plants +home rec:1w due:2030-06-02T10:00:00+02:00",
+			want: storage.Task{Text: "water plants rec:1w", Tags: []string{"home"}, Due: "2030-06-02T08:00:00Z"},
+		},
+		{
+			name:    "mapping",
+			line:    "(a) plan sprint +Work",
+			mapping: map[string]string{" work ": "office"},
+			want:    storage.Task{Text: "(a) plan sprint", Tags: []string{"office"}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			task, err := ParseTodoTxtLine(test.line, test.mapping)
+			if err != nil {
+				t.Fatalf("ParseTodoTxtLine(%q): %v", test.line, err)
+			}
+			assertTask(t, test.line, task, test.want)
+		})
+	}
+}
+
+func TestParseTodoTxtLineError(t *testing.T) {
+	for _, line := range []string{"pay rent +home due:soon", "x pay rent +home pri:1"} {
+		if _, err := ParseTodoTxtLine(line, nil); err == nil {
+			t.Errorf("ParseTodoTxtLine(%q): expect error", line)
+		}
+	}
+}
+
+// assertTask compares imported fields of tasks, line - imported line
+func assertTask(t *testing.T, line string, got, want storage.Task) {
+	t.Helper()
+	if got.Text != want.Text || !slices.Equal(got.Tags, want.Tags) || got.Due != want.Due ||
+		got.Priority != want.Priority || got.Done != want.Done {
+		t.Errorf("line %q\ngot:  %+v\nwant: %+v", line, got, want)
+	}
+}
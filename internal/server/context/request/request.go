@@ -8,9 +8,113 @@ type Request interface {
 
 // TaskRequest http request struct
 type TaskRequest struct {
-	Text string   `json:"text" validate:"required, max=100"`
-	Tags []string `json:"tags" validate:"required"`
-	Due  string   `json:"due" validate:"required"`
+	Text      string   `json:"text" validate:"required, max=100"`
+	Tags      []string `json:"tags" validate:"required"`
+	Due       string   `json:"due" validate:"required"`
+	ProjectId int      `json:"project_id"`
+	Assignee  string   `json:"assignee"`
+	Priority  string   `json:"priority"`
+	ParentId  int      `json:"parent_id"`
+}
+
+// TaskUpdateRequest http request struct for task changing, missing field is not changed.
+// Empty assignee removes assignee of the task, parent_id = 0 moves task to the top level
+type TaskUpdateRequest struct {
+	Text     *string  `json:"text" validate:"max=100"`
+	Tags     []string `json:"tags"`
+	Due      *string  `json:"due"`
+	Assignee *string  `json:"assignee"`
+	Priority *string  `json:"priority"`
+	ParentId *int     `json:"parent_id"`
+	Done     *bool    `json:"done"`
+}
+
+func (t *TaskUpdateRequest) Request() bool {
+	return true
+}
+
+// ChecklistItemRequest http request struct for checklist item creation and changing, missing field is not changed
+type ChecklistItemRequest struct {
+	Text *string `json:"text" validate:"max=100"`
+	Done *bool   `json:"done"`
+}
+
+func (c *ChecklistItemRequest) Request() bool {
+	return true
+}
+
+// CommentRequest http request struct for comment creation, text is markdown
+type CommentRequest struct {
+	Text string `json:"text" validate:"required, max=10000"`
+}
+
+func (c *CommentRequest) Request() bool {
+	return true
+}
+
+// MoveRequest http request struct for moving task right before or right after other task, only one of fields is set
+type MoveRequest struct {
+	Before int `json:"before"`
+	After  int `json:"after"`
+}
+
+func (m *MoveRequest) Request() bool {
+	return true
+}
+
+// DependencyRequest http request struct for adding blocker of the task
+type DependencyRequest struct {
+	TaskId int `json:"task_id" validate:"required"`
+}
+
+func (d *DependencyRequest) Request() bool {
+	return true
+}
+
+// batch operations and modes
+const (
+	BatchCreate   = "create"
+	BatchUpdate   = "update"
+	BatchDelete   = "delete"
+	BatchComplete = "complete"
+	// BatchAtomic all operations are applied or none of them
+	BatchAtomic = "atomic"
+	// BatchPartial failed operations are skipped, other operations are applied
+	BatchPartial = "partial"
+)
+
+// MaxBatchOperations max number of operations in one batch
+const MaxBatchOperations = 100
+
+// BatchRequest http request struct for bulk task operations, mode - atomic (by default) or partial
+type BatchRequest struct {
+	Mode       string           `json:"mode"`
+	Operations []BatchOperation `json:"operations" validate:"required"`
+}
+
+func (b *BatchRequest) Request() bool {
+	return true
+}
+
+// BatchOperation operation of the batch: create - task is required,
+// update - id and changes are required, delete and complete - id is required
+type BatchOperation struct {
+	Op      string             `json:"op" validate:"required"`
+	Id      int                `json:"id"`
+	Task    *TaskRequest       `json:"task"`
+	Changes *TaskUpdateRequest `json:"changes"`
+}
+
+// BulkTagRequest http request struct for adding and removing tags of tasks matching filter,
+// dry_run - return matching tasks without changing them
+type BulkTagRequest struct {
+	Add    []string `json:"add"`
+	Remove []string `json:"remove"`
+	DryRun bool     `json:"dry_run"`
+}
+
+func (b *BulkTagRequest) Request() bool {
+	return true
 }
 
 type TagsRequest struct {
@@ -26,9 +130,58 @@ func (t *TaskRequest) Request() bool {
 }
 
 type TagRequest struct {
-	Name string `json:"name" validate:"required, max=100"`
+	Name      string `json:"name" validate:"required, max=100"`
+	ProjectId int    `json:"project_id"`
 }
 
 func (t *TagRequest) Request() bool {
 	return true
 }
+
+// ProjectRequest http request struct for project creation and renaming
+type ProjectRequest struct {
+	Name string `json:"name" validate:"required, max=100"`
+}
+
+func (p *ProjectRequest) Request() bool {
+	return true
+}
+
+// UserRequest http request struct for registration and login
+type UserRequest struct {
+	Name     string `json:"name" validate:"required, min=3, max=32"`
+	Password string `json:"password" validate:"required, min=8, max=72"`
+}
+
+func (u *UserRequest) Request() bool {
+	return true
+}
+
+// ApiKeyRequest http request struct for api key creation, empty role means role of the user
+type ApiKeyRequest struct {
+	Name string `json:"name" validate:"required, max=100"`
+	Role string `json:"role"`
+}
+
+func (a *ApiKeyRequest) Request() bool {
+	return true
+}
+
+// RoleRequest http request struct for changing user role
+type RoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+func (r *RoleRequest) Request() bool {
+	return true
+}
+
+// ShareRequest http request struct for sharing task or project with the user
+type ShareRequest struct {
+	User       string `json:"user" validate:"required"`
+	Permission string `json:"permission" validate:"required"`
+}
+
+func (s *ShareRequest) Request() bool {
+	return true
+}
@@ -0,0 +1,21 @@
+package request
+
+import (
+	"context"
+	"web/internal/storage"
+)
+
+type contextKey string
+
+const userKey contextKey = "user"
+
+// WithUser returns copy of ctx with authenticated user
+func WithUser(ctx context.Context, user *storage.User) context.Context {
+	return context.WithValue(ctx, userKey, user)
+}
+
+// UserFromContext returns authenticated user, nil if request is not authenticated
+func UserFromContext(ctx context.Context) *storage.User {
+	user, _ := ctx.Value(userKey).(*storage.User)
+	return user
+}
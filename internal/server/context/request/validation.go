@@ -2,8 +2,10 @@ package request
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"time"
+	"web/internal/storage"
 	tagsList "web/storage/tags-list"
 )
 
@@ -18,6 +20,17 @@ func (m MultiError) Error() string {
 }
 
 func (t *TaskRequest) ValidateRequest(allTagsList *tagsList.TagsList) error {
+	return t.validate(allTagsList, true)
+}
+
+// ValidateImport validates task read from imported file, due can be in the past,
+// so exported overdue tasks can be imported back
+func (t *TaskRequest) ValidateImport(allTagsList *tagsList.TagsList) error {
+	return t.validate(allTagsList, false)
+}
+
+// validate validates fields of the task, future - due must be in the future
+func (t *TaskRequest) validate(allTagsList *tagsList.TagsList, future bool) error {
 	var errors MultiError
 
 	err := t.validateText()
@@ -30,7 +43,21 @@ func (t *TaskRequest) ValidateRequest(allTagsList *tagsList.TagsList) error {
 		errors = append(errors, err)
 	}
 
-	err = t.ValidateDue()
+	if future {
+		err = t.ValidateDue()
+	} else {
+		_, err = t.parseDue()
+	}
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = t.ValidatePriority()
+	if err != nil {
+		errors = append(errors, err)
+	}
+
+	err = t.validateParent()
 	if err != nil {
 		errors = append(errors, err)
 	}
@@ -41,6 +68,13 @@ func (t *TaskRequest) ValidateRequest(allTagsList *tagsList.TagsList) error {
 	return nil
 }
 
+func (t *TaskRequest) validateParent() error {
+	if t.ParentId < 0 {
+		return fmt.Errorf("expect parent_id as positive integer")
+	}
+	return nil
+}
+
 func (t *TaskRequest) validateText() error {
 	if len(t.Text) <= 0 {
 		return fmt.Errorf("expect non-empty text")
@@ -80,18 +114,243 @@ func (t *TaskRequest) ValidateDue() error {
 	//if err != nil {
 	//	return nil, fmt.Errorf("invalid date format")
 	//}
+	date, err := t.parseDue()
+	if err != nil {
+		return err
+	}
+
+	if date.Before(time.Now()) {
+		return fmt.Errorf("expect due date in the future")
+	}
+
+	return nil
+}
+
+// parseDue returns due of the task, error if due is not in RFC3339 format or zero
+func (t *TaskRequest) parseDue() (time.Time, error) {
 	date, err := time.Parse(time.RFC3339, t.Due)
 	if err != nil {
-		return fmt.Errorf("expect due date in RFC3339 format, given: '%v'", t.Due)
+		return date, fmt.Errorf("expect due date in RFC3339 format, given: '%v'", t.Due)
 	}
 
 	if date.IsZero() {
-		return fmt.Errorf("expect non-zero due date, given: %v", t.Due)
+		return date, fmt.Errorf("expect non-zero due date, given: %v", t.Due)
 	}
+	return date, nil
+}
 
-	if date.Before(time.Now()) {
-		return fmt.Errorf("expect due date in the future")
+func (t *TaskUpdateRequest) ValidateRequest(allTagsList *tagsList.TagsList) error {
+	var errors MultiError
+
+	if t.Text == nil && t.Tags == nil && t.Due == nil && t.Assignee == nil && t.Priority == nil &&
+		t.ParentId == nil && t.Done == nil {
+		return fmt.Errorf("expect at least one of: text, tags, due, assignee, priority, parent_id, done")
+	}
+
+	// fields are validated same as fields of the new task
+	task := TaskRequest{Tags: t.Tags}
+	if t.Text != nil {
+		task.Text = *t.Text
+		if err := task.validateText(); err != nil {
+			errors = append(errors, err)
+		}
 	}
+	if t.Tags != nil {
+		if err := task.ValidateTags(allTagsList); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	if t.Due != nil {
+		task.Due = *t.Due
+		if err := task.ValidateDue(); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	if t.Priority != nil {
+		if _, err := storage.ParsePriority(*t.Priority); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	if t.ParentId != nil && *t.ParentId < 0 {
+		errors = append(errors, fmt.Errorf("expect parent_id as positive integer or 0"))
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+// ValidateRequest checks checklist item, create - text is required
+func (c *ChecklistItemRequest) ValidateRequest(create bool) error {
+	switch {
+	case c.Text == nil && c.Done == nil:
+		return fmt.Errorf("expect at least one of: text, done")
+	case c.Text == nil && create:
+		return fmt.Errorf("expect non-empty text")
+	case c.Text != nil && (len(strings.TrimSpace(*c.Text)) == 0 || len(*c.Text) > 100):
+		return fmt.Errorf("expect text from 1 to 100 characters")
+	}
+	return nil
+}
 
+// ValidateRequest checks mode and number of operations, operations are validated one by one with ValidateOperation
+func (b *BatchRequest) ValidateRequest() error {
+	var errors MultiError
+
+	if b.Mode != "" && b.Mode != BatchAtomic && b.Mode != BatchPartial {
+		errors = append(errors, fmt.Errorf("unknown mode '%s', expect one of: %s, %s", b.Mode, BatchAtomic, BatchPartial))
+	}
+	if len(b.Operations) == 0 || len(b.Operations) > MaxBatchOperations {
+		errors = append(errors, fmt.Errorf("expect from 1 to %d operations", MaxBatchOperations))
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+// ValidateOperation checks fields required by operation, task and changes are validated as task requests
+func (b *BatchOperation) ValidateOperation() error {
+	switch b.Op {
+	case BatchCreate:
+		if b.Task == nil {
+			return fmt.Errorf("expect task to create")
+		}
+	case BatchUpdate:
+		if b.Changes == nil {
+			return fmt.Errorf("expect changes of the task")
+		}
+		fallthrough
+	case BatchDelete, BatchComplete:
+		if b.Id <= 0 {
+			return fmt.Errorf("expect id of the task as positive integer")
+		}
+	default:
+		return fmt.Errorf("unknown operation '%s', expect one of: %s, %s, %s, %s",
+			b.Op, BatchCreate, BatchUpdate, BatchDelete, BatchComplete)
+	}
+	return nil
+}
+
+// ValidateRequest checks that tags are changed, tags to add are validated against tags of the project of each task
+func (b *BulkTagRequest) ValidateRequest() error {
+	if len(b.Add) == 0 && len(b.Remove) == 0 {
+		return fmt.Errorf("expect at least one of: add, remove")
+	}
+
+	var errors MultiError
+	for _, tag := range b.Add {
+		if slices.Contains(b.Remove, tag) {
+			errors = append(errors, fmt.Errorf("tag '%s' is both added and removed", tag))
+		}
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+func (c *CommentRequest) ValidateRequest() error {
+	if len(strings.TrimSpace(c.Text)) == 0 || len(c.Text) > 10000 {
+		return fmt.Errorf("expect comment text from 1 to 10000 characters")
+	}
+	return nil
+}
+
+func (m *MoveRequest) ValidateRequest() error {
+	if m.Before < 0 || m.After < 0 || (m.Before == 0) == (m.After == 0) {
+		return fmt.Errorf("expect one of: before, after as id of other task")
+	}
+	return nil
+}
+
+func (d *DependencyRequest) ValidateRequest() error {
+	if d.TaskId <= 0 {
+		return fmt.Errorf("expect task_id of blocker task as positive integer")
+	}
+	return nil
+}
+
+func (s *ShareRequest) ValidateRequest() error {
+	var errors MultiError
+
+	if len(s.User) == 0 {
+		errors = append(errors, fmt.Errorf("expect non-empty user"))
+	}
+	if s.Permission != storage.PermissionRead && s.Permission != storage.PermissionWrite {
+		errors = append(errors, fmt.Errorf("unknown permission '%s', expect one of: %s, %s",
+			s.Permission, storage.PermissionRead, storage.PermissionWrite))
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+// ValidatePriority checks priority name, empty priority means default priority
+func (t *TaskRequest) ValidatePriority() error {
+	if t.Priority == "" {
+		return nil
+	}
+	_, err := storage.ParsePriority(t.Priority)
+	return err
+}
+
+func (u *UserRequest) ValidateRequest() error {
+	var errors MultiError
+
+	if len(u.Name) < 3 || len(u.Name) > 32 {
+		errors = append(errors, fmt.Errorf("expect user name from 3 to 32 characters"))
+	}
+	for _, r := range u.Name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_' || r == '-' || r == '.') {
+			errors = append(errors, fmt.Errorf("user name may contain only latin letters, digits and '_', '-', '.'"))
+			break
+		}
+	}
+
+	// bcrypt uses only first 72 bytes of password
+	if len(u.Password) < 8 || len(u.Password) > 72 {
+		errors = append(errors, fmt.Errorf("expect password from 8 to 72 characters"))
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+func (a *ApiKeyRequest) ValidateRequest() error {
+	var errors MultiError
+
+	if len(a.Name) == 0 || len(a.Name) > 100 {
+		errors = append(errors, fmt.Errorf("expect api key name from 1 to 100 characters"))
+	}
+	if a.Role != "" && !storage.ValidRole(a.Role) {
+		errors = append(errors, fmt.Errorf("unknown role '%s'", a.Role))
+	}
+
+	if len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+func (r *RoleRequest) ValidateRequest() error {
+	if !storage.ValidRole(r.Role) {
+		return fmt.Errorf("unknown role '%s', expect one of: %s, %s, %s",
+			r.Role, storage.RoleAdmin, storage.RoleMember, storage.RoleReadOnly)
+	}
+	return nil
+}
+
+func (p *ProjectRequest) ValidateRequest() error {
+	if len(strings.TrimSpace(p.Name)) == 0 || len(p.Name) > 100 {
+		return fmt.Errorf("expect project name from 1 to 100 characters")
+	}
 	return nil
 }
@@ -1,5 +1,7 @@
 package response
 
+import "web/internal/storage"
+
 type Response interface {
 	// GetStatus returns status code
 	GetStatus() int
@@ -32,6 +34,53 @@ func (o *OkResponseEmpty) GetStatus() int {
 	return o.Status
 }
 
+// BatchResponse response of bulk operations with result of each operation,
+// Error - errors of failed operations separated by '; '
+type BatchResponse struct {
+	Status  int           `json:"status"`
+	Error   string        `json:"error,omitempty"`
+	Results []BatchResult `json:"results"`
+}
+
+func (b *BatchResponse) GetStatus() int {
+	return b.Status
+}
+
+// BatchResult result of operation of the batch, Status - http status of the operation
+type BatchResult struct {
+	Status int           `json:"status"`
+	Task   *storage.Task `json:"task,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// BulkTagResult result of bulk tag change, Count - number of changed tasks.
+// Dry run doesn't change tasks, Count - number of tasks to change and Tasks - all matching tasks
+type BulkTagResult struct {
+	Count int            `json:"count"`
+	Tasks []storage.Task `json:"tasks,omitempty"`
+}
+
+// ImportResult report of tasks import, Created - number of created tasks, Failed - number of skipped records
+type ImportResult struct {
+	Created int         `json:"created"`
+	Failed  int         `json:"failed"`
+	Rows    []ImportRow `json:"rows"`
+}
+
+// ImportRow result of import of the record, Line - line of the record in the file, Status - http status of the record
+type ImportRow struct {
+	Line   int           `json:"line"`
+	Status int           `json:"status"`
+	Task   *storage.Task `json:"task,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// CalendarToken secret token of calendar feed, Url - path of the feed with the token
+type CalendarToken struct {
+	Token string `json:"token"`
+	Url   string `json:"url"`
+}
+
 // Error create new response with error.
 // status - status code for error.
 // err - error (not string)
@@ -1,10 +1,17 @@
 package middleware
 
 import (
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strings"
 	"time"
+	"web/internal/auth"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/server/policy"
+	"web/internal/storage"
 )
 
 // MB Todo middleware structure
@@ -27,11 +34,27 @@ func HandlerExecutionTime(log *slog.Logger) func(next http.Handler) http.Handler
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			start := time.Now()
 			next.ServeHTTP(w, req)
-			log.Info("Request: ", slog.String("method", req.Method), slog.String("path", req.RequestURI), slog.String("time", time.Since(start).String()))
+			log.Info("Request: ", slog.String("method", req.Method), slog.String("path", requestPath(req)), slog.String("time", time.Since(start).String()))
 		})
 	}
 }
 
+// requestPath returns path and query of the request for logs, value of CalendarTokenParam is hidden,
+// so secret calendar token is not written to logs
+func requestPath(req *http.Request) string {
+	if !strings.Contains(req.URL.RawQuery, CalendarTokenParam) {
+		return req.RequestURI
+	}
+	// query is parsed again, so malformed pairs which can contain token are dropped
+	query := req.URL.Query()
+	if query.Has(CalendarTokenParam) {
+		query.Set(CalendarTokenParam, "hidden")
+	}
+	path := *req.URL
+	path.RawQuery = query.Encode()
+	return path.RequestURI()
+}
+
 // HandlerExecutionTimeV2 middleware for one method
 // r.Get("/{id:\\d*}", middleware.HandlerExecutionTimeV2(http.HandlerFunc(server.Handlers.GetTaskHandler)))
 func HandlerExecutionTimeV2(next http.Handler) http.HandlerFunc {
@@ -47,8 +70,116 @@ func HandlerExecutionTimeV2(next http.Handler) http.HandlerFunc {
 func HandlerExecutionTimeV3(log *slog.Logger) func(next http.Handler) http.HandlerFunc {
 	return func(next http.Handler) http.HandlerFunc {
 		return func(w http.ResponseWriter, req *http.Request) {
-			log.Info(fmt.Sprintf("%s %s", req.Method, req.RequestURI))
+			log.Info(fmt.Sprintf("%s %s", req.Method, requestPath(req)))
 			next.ServeHTTP(w, req)
 		}
 	}
 }
+
+// content security policies of responses
+const (
+	// ApiCSP policy of the API and web UI: only own scripts, styles and requests, page can't be framed
+	ApiCSP = "default-src 'self'; object-src 'none'; base-uri 'none'; form-action 'self'; frame-ancestors 'none'"
+	// SwaggerCSP policy of swagger page, it has inline scripts and styles
+	SwaggerCSP = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; " +
+		"img-src 'self' data:; object-src 'none'; base-uri 'none'; frame-ancestors 'none'"
+)
+
+// SecurityHeaders sets content security policy and headers which forbid content sniffing, framing and referrer.
+// Route can override policy of the router by its own SecurityHeaders
+func SecurityHeaders(csp string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.Header().Set("Content-Security-Policy", csp)
+			w.Header().Set("X-Content-Type-Options", "nosniff")
+			w.Header().Set("X-Frame-Options", "DENY")
+			w.Header().Set("Referrer-Policy", "no-referrer")
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// ApiKeyHeader header with api key, alternative to session token
+const ApiKeyHeader = "X-API-Key"
+
+// Authenticate resolves user from 'Authorization: Bearer <token>' or 'X-API-Key: <key>' header
+// and stores him in request context.
+// Requests without valid session or api key are rejected with 401.
+func Authenticate(db storage.Storage) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var user *storage.User
+			var err error
+
+			if key := req.Header.Get(ApiKeyHeader); key != "" {
+				user, err = db.GetUserByApiKey(auth.HashToken(key))
+			} else if token, ok := BearerToken(req); ok {
+				user, err = db.GetUserBySession(auth.HashToken(token))
+			} else {
+				writeError(w, response.Error(http.StatusUnauthorized, fmt.Errorf("authorization required")))
+				return
+			}
+			if err != nil {
+				writeError(w, response.Error(http.StatusUnauthorized, fmt.Errorf("invalid or expired token")))
+				return
+			}
+
+			next.ServeHTTP(w, req.WithContext(request.WithUser(req.Context(), user)))
+		})
+	}
+}
+
+// CalendarTokenParam query param with calendar feed token, calendar apps can't send headers
+const CalendarTokenParam = "token"
+
+// AuthenticateCalendar resolves user from calendar feed token in CalendarTokenParam query param
+// and stores him in request context.
+// Requests without valid token are rejected with 401.
+func AuthenticateCalendar(db storage.Storage) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			token := req.URL.Query().Get(CalendarTokenParam)
+			if token == "" {
+				writeError(w, response.Error(http.StatusUnauthorized, fmt.Errorf("calendar token required")))
+				return
+			}
+			user, err := db.GetUserByCalendarToken(auth.HashToken(token))
+			if err != nil {
+				writeError(w, response.Error(http.StatusUnauthorized, fmt.Errorf("invalid calendar token")))
+				return
+			}
+			next.ServeHTTP(w, req.WithContext(request.WithUser(req.Context(), user)))
+		})
+	}
+}
+
+// RequirePermission rejects request with 403 if role of the user has no permission.
+// Must be used after Authenticate.
+func RequirePermission(permission policy.Permission) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			user := request.UserFromContext(req.Context())
+			if user == nil || !policy.Allowed(user.Role, permission) {
+				writeError(w, response.Error(http.StatusForbidden, fmt.Errorf("permission '%s' required", permission)))
+				return
+			}
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
+// BearerToken returns token from 'Authorization: Bearer <token>' header
+func BearerToken(req *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// writeError writes error response, middlewares don't have access to handlers.JSON
+func writeError(w http.ResponseWriter, resp response.Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(resp.GetStatus())
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+	"web/internal/auth"
+	"web/internal/config"
+	"web/internal/server/context/request"
+	"web/internal/storage"
+	"web/internal/storage/sqlite"
+)
+
+// newTestStorage returns sqlite storage with migrated database in temporary directory of the test and its first user
+func newTestStorage(t *testing.T) (storage.Storage, *storage.User) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.DatabaseConfig.Config = map[string]string{"storagePath": filepath.Join(t.TempDir(), "test.db")}
+	db := (&sqlite.StoreSqlite{}).Connect(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() { _ = db.Close() })
+
+	user, err := db.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, user
+}
+
+func TestAuthenticate(t *testing.T) {
+	db, user := newTestStorage(t)
+	if err := db.CreateSession(user.Id, auth.HashToken("valid"), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.CreateSession(user.Id, auth.HashToken("expired"), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{name: "valid session", header: "Authorization", value: "Bearer valid", wantStatus: http.StatusOK},
+		{name: "expired session", header: "Authorization", value: "Bearer expired", wantStatus: http.StatusUnauthorized},
+		{name: "hash of session token", header: "Authorization", value: "Bearer " + auth.HashToken("valid"), wantStatus: http.StatusUnauthorized},
+		{name: "unknown session", header: "Authorization", value: "Bearer unknown", wantStatus: http.StatusUnauthorized},
+		{name: "not bearer token", header: "Authorization", value: "Basic valid", wantStatus: http.StatusUnauthorized},
+		{name: "no token", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *storage.User
+			handler := Authenticate(db)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = request.UserFromContext(r.Context())
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/task/", nil)
+			if tt.header != "" {
+				r.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK && (got == nil || got.Id != user.Id) {
+				t.Errorf("user in context = %v, want %v", got, user)
+			}
+		})
+	}
+}
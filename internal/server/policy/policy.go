@@ -0,0 +1,52 @@
+package policy
+
+import (
+	"web/internal/storage"
+)
+
+// Permission name of action checked per route
+type Permission string
+
+const (
+	TasksRead      Permission = "tasks:read"
+	TasksWrite     Permission = "tasks:write"
+	TasksDeleteAll Permission = "tasks:delete_all"
+	TagsRead       Permission = "tags:read"
+	TagsWrite      Permission = "tags:write"
+	TagsDeleteAll  Permission = "tags:delete_all"
+	ProjectsRead   Permission = "projects:read"
+	ProjectsWrite  Permission = "projects:write"
+	KeysWrite      Permission = "keys:write"
+	UsersManage    Permission = "users:manage"
+	AuditRead      Permission = "audit:read"
+	DatabaseManage Permission = "database:manage"
+)
+
+// readOnly permissions of read-only role, it is limited to GET routes
+var readOnly = []Permission{TasksRead, TagsRead, ProjectsRead}
+
+// member permissions of member role, bulk deletes are not included
+var member = append([]Permission{TasksWrite, TagsWrite, ProjectsWrite, KeysWrite}, readOnly...)
+
+// admin permissions of admin role
+var admin = append([]Permission{TasksDeleteAll, TagsDeleteAll, UsersManage, AuditRead, DatabaseManage}, member...)
+
+// roles permissions of each role
+var roles = map[string]map[Permission]bool{
+	storage.RoleReadOnly: toSet(readOnly),
+	storage.RoleMember:   toSet(member),
+	storage.RoleAdmin:    toSet(admin),
+}
+
+// Allowed reports whether role has permission, unknown roles have no permissions
+func Allowed(role string, permission Permission) bool {
+	return roles[role][permission]
+}
+
+func toSet(permissions []Permission) map[Permission]bool {
+	set := make(map[Permission]bool, len(permissions))
+	for _, p := range permissions {
+		set[p] = true
+	}
+	return set
+}
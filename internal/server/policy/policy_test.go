@@ -0,0 +1,72 @@
+package policy
+
+import (
+	"testing"
+	"web/internal/storage"
+)
+
+func TestAllowed(t *testing.T) {
+	// permissions of each role: read-only, member, admin
+	tests := []struct {
+		permission Permission
+		readOnly   bool
+		member     bool
+		admin      bool
+	}{
+		{TasksRead, true, true, true},
+		{TasksWrite, false, true, true},
+		{TasksDeleteAll, false, false, true},
+		{TagsRead, true, true, true},
+		{TagsWrite, false, true, true},
+		{TagsDeleteAll, false, false, true},
+		{ProjectsRead, true, true, true},
+		{ProjectsWrite, false, true, true},
+		{KeysWrite, false, true, true},
+		{UsersManage, false, false, true},
+		{AuditRead, false, false, true},
+		{DatabaseManage, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.permission), func(t *testing.T) {
+			want := map[string]bool{
+				storage.RoleReadOnly: tt.readOnly,
+				storage.RoleMember:   tt.member,
+				storage.RoleAdmin:    tt.admin,
+				"unknown":            false,
+				"":                   false,
+			}
+			for role, allowed := range want {
+				if got := Allowed(role, tt.permission); got != allowed {
+					t.Errorf("Allowed(%q, %q) = %v, want %v", role, tt.permission, got, allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestWeakerRole(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{storage.RoleAdmin, storage.RoleAdmin, storage.RoleAdmin},
+		{storage.RoleAdmin, storage.RoleMember, storage.RoleMember},
+		{storage.RoleMember, storage.RoleAdmin, storage.RoleMember},
+		{storage.RoleAdmin, storage.RoleReadOnly, storage.RoleReadOnly},
+		{storage.RoleReadOnly, storage.RoleMember, storage.RoleReadOnly},
+		{storage.RoleMember, storage.RoleMember, storage.RoleMember},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := storage.WeakerRole(tt.a, tt.b); got != tt.want {
+				t.Errorf("WeakerRole(%q, %q) = %q, want %q", tt.a, tt.b, got, tt.want)
+			}
+			// key with weaker role never gets permission its user doesn't have
+			for _, tp := range []Permission{TasksWrite, UsersManage, DatabaseManage} {
+				if Allowed(tt.want, tp) && !(Allowed(tt.a, tp) && Allowed(tt.b, tp)) {
+					t.Errorf("weaker role %q has permission %q", tt.want, tp)
+				}
+			}
+		})
+	}
+}
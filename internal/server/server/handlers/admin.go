@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+// defaultMaxRestoreSize max size of restored snapshot if it is not set in config, 1 GB
+const defaultMaxRestoreSize = 1 << 30
+
+// BackupHandler returns snapshot of the database
+// @Summary Backup database
+// @Description Download consistent snapshot of all data without stopping the server, sqlite snapshot is database file. Files of attachments are not included. Admin only
+// @Tags admin
+// @Produce octet-stream
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {file} file
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/backup [post]
+func (h *Handlers) BackupHandler(w http.ResponseWriter, r *http.Request) {
+	name := fmt.Sprintf("backup-%s.db", time.Now().UTC().Format("20060102T150405Z"))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+
+	// snapshot is written only when it is complete, so failed backup returns error response
+	err := h.Db.Backup(w)
+	if err != nil {
+		w.Header().Del("Content-Disposition")
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("can't backup database")))
+	}
+}
+
+// RestoreHandler replaces all data with snapshot of the database
+// @Summary Restore database
+// @Description Replace all data with snapshot from POST /admin/backup in body, including users and sessions. Snapshot of newer schema version is rejected, snapshot of older schema version is migrated. Max size of snapshot is set in config. Admin only
+// @Tags admin
+// @Accept octet-stream
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param snapshot body string true "Snapshot"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 413 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /admin/restore [post]
+func (h *Handlers) RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	maxSize := h.Cfg.Admin.MaxRestoreSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxRestoreSize
+	}
+
+	// snapshot is copied to temporary file, so its size is limited
+	err := h.Db.Restore(http.MaxBytesReader(w, r.Body, maxSize))
+	if err != nil {
+		var errSize *http.MaxBytesError
+		if errors.As(err, &errSize) {
+			h.JSON(w, response.Error(http.StatusRequestEntityTooLarge, fmt.Errorf("snapshot is larger than %d bytes", maxSize)))
+			return
+		}
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("can't restore database")))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK())
+}
@@ -0,0 +1,305 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/go-chi/chi"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+// defaultMaxAttachmentSize max size of attachment file if it is not set in config, 10 MB
+const defaultMaxAttachmentSize = 10 << 20
+
+// GetAttachmentsHandler returns attachments of the task
+// @Summary Get attachments
+// @Description Get attachments of the task from the oldest one
+// @Tags attachments
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Success 200 {object} response.OkResponse{data=storage.Attachments}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/attachment [get]
+func (h *Handlers) GetAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	attachments, err := h.Db.GetAttachments(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(attachments))
+}
+
+// CreateAttachmentHandler uploads file to the task
+// @Summary Upload attachment
+// @Description Upload file to the task as multipart/form-data 'file' field. Size and MIME type detected by content
+// @Description are limited by config, file with same content is stored once
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param file formData file true "File"
+// @Success 200 {object} response.OkResponse{data=storage.Attachment}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 413 {object} response.ErrorResponse
+// @Failure 415 {object} response.ErrorResponse
+// @Router /task/{id}/attachment [post]
+func (h *Handlers) CreateAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	// access is checked before body is read, so file of forbidden upload is never stored
+	err = h.Db.CheckTaskWriteAccess(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, fmt.Errorf("expect multipart/form-data body: %v", err)))
+		return
+	}
+	var part io.ReadCloser
+	var name string
+	for {
+		p, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			h.JSON(w, response.Error(http.StatusBadRequest, fmt.Errorf("expect file in 'file' field")))
+			return
+		}
+		if err != nil {
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+			return
+		}
+		if p.FormName() == "file" {
+			part, name = p, filepath.Base(p.FileName())
+			break
+		}
+	}
+	if name == "." || name == string(filepath.Separator) {
+		h.JSON(w, response.Error(http.StatusBadRequest, fmt.Errorf("expect file name")))
+		return
+	}
+
+	maxSize := h.Cfg.Attachments.MaxSize
+	if maxSize <= 0 {
+		maxSize = defaultMaxAttachmentSize
+	}
+	content := http.MaxBytesReader(w, part, maxSize)
+
+	// MIME type is detected by the first 512 bytes of content, type from request can't be trusted
+	head := make([]byte, 512)
+	n, err := io.ReadFull(content, head)
+	switch {
+	case n == 0:
+		h.JSON(w, response.Error(http.StatusBadRequest, fmt.Errorf("file is empty")))
+		return
+	case err != nil && !errors.Is(err, io.ErrUnexpectedEOF):
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	mimeType, _, _ := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if !h.allowedType(mimeType) {
+		h.JSON(w, response.Error(http.StatusUnsupportedMediaType, fmt.Errorf("file type '%s' is not allowed", mimeType)))
+		return
+	}
+
+	blob, err := h.Blobs.Stage(io.MultiReader(bytes.NewReader(head[:n]), content))
+	if err != nil {
+		var errSize *http.MaxBytesError
+		if errors.As(err, &errSize) {
+			h.JSON(w, response.Error(http.StatusRequestEntityTooLarge, fmt.Errorf("file is larger than %d bytes", maxSize)))
+			return
+		}
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("can't store file")))
+		return
+	}
+	defer h.Blobs.Discard(blob)
+
+	// upload is not locked, lock is held only from commit of the blob to creation of its attachment,
+	// so unused blobs are not deleted in between
+	h.BlobsMu.Lock()
+	defer h.BlobsMu.Unlock()
+
+	err = h.Blobs.Commit(blob)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("can't store file")))
+		return
+	}
+
+	attachment, err := h.Db.CreateAttachment(user.Id, id, name, mimeType, blob)
+	if err != nil {
+		// new blob is not used by other attachments
+		if blob.New {
+			_ = h.Blobs.Delete(blob.Hash)
+		}
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(attachment))
+}
+
+// DownloadAttachmentHandler returns file of the attachment
+// @Summary Download attachment
+// @Description Download file of the attachment, supports range and If-None-Match requests, ETag is sha256 of the file
+// @Tags attachments
+// @Produce octet-stream
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param attachment path int true "Attachment id"
+// @Success 200 {file} file
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/attachment/{attachment} [get]
+func (h *Handlers) DownloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	attachmentId, err := strconv.Atoi(chi.URLParam(r, "attachment"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	attachment, err := h.Db.GetAttachment(user.Id, id, attachmentId)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	content, err := h.Blobs.Open(attachment.Hash)
+	if err != nil {
+		h.Log.Error(fmt.Sprintf("handlers.DownloadAttachmentHandler: %v", err))
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("can't read file")))
+		return
+	}
+	defer content.Close()
+
+	// file is always downloaded and never rendered by browser, so uploaded html or svg can't run scripts
+	w.Header().Set("Content-Type", attachment.MimeType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "sandbox")
+	w.Header().Set("ETag", `"`+attachment.Hash+`"`)
+	http.ServeContent(w, r, attachment.Name, time.Time{}, content)
+}
+
+// DeleteAttachmentHandler deletes attachment of the task
+// @Summary Delete attachment
+// @Description Delete attachment, only author of the attachment and owner of the task can delete it
+// @Tags attachments
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param attachment path int true "Attachment id"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/attachment/{attachment} [delete]
+func (h *Handlers) DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	attachmentId, err := strconv.Atoi(chi.URLParam(r, "attachment"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	err = h.Db.DeleteAttachment(user.Id, id, attachmentId)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.DeleteUnusedBlobs()
+
+	h.JSON(w, response.OK())
+}
+
+// allowedType reports whether MIME type is allowed by config, empty config allows any type
+func (h *Handlers) allowedType(mimeType string) bool {
+	allowed := h.Cfg.Attachments.AllowedTypes
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, pattern := range allowed {
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mimeType, prefix+"/") {
+				return true
+			}
+		} else if mimeType == pattern {
+			return true
+		}
+	}
+	return false
+}
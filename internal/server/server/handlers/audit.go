@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"net/http"
+	"strconv"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+// GetTaskHistoryHandler returns changes of the task
+// @Summary Get task history
+// @Description Get changes of the task and its comments, attachments, checklist, dependencies and shares from the oldest one.
+// @Description Each change has json snapshots of the entity before and after change. History of deleted task is visible to its last owner
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Success 200 {object} response.OkResponse{data=storage.AuditLog}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/history [get]
+func (h *Handlers) GetTaskHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	history, err := h.Db.GetTaskHistory(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(history))
+}
+
+// GetAuditLogHandler returns changes of all users
+// @Summary Get audit log
+// @Description Get the newest changes of tasks, tags and projects of all users from the newest one, admin only
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param actor query string false "Name of the user who made change"
+// @Param entity query string false "Entity: task, tag, project, comment, attachment, checklist_item, dependency or share"
+// @Param entity_id query int false "Entity id"
+// @Param task query int false "Task id, changes of the task and its parts"
+// @Param from query string false "Changes made at or after time, format: 2006-01-02T15:04:05Z"
+// @Param to query string false "Changes made before time, format: 2006-01-02T15:04:05Z"
+// @Param limit query int false "Max number of entries, default 100, up to 1000"
+// @Success 200 {object} response.OkResponse{data=storage.AuditLog}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /audit [get]
+func (h *Handlers) GetAuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := auditFilter(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	log, err := h.Db.GetAuditLog(filter)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+
+	h.JSON(w, response.OK(log))
+}
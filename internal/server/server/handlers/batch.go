@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+	tagsList "web/storage/tags-list"
+)
+
+// BatchTasksHandler applies operations with tasks in one transaction
+// @Summary Bulk task operations
+// @Description Apply up to 100 operations with tasks in one transaction, operations are applied in order of the request.
+// @Description Operation: create - task is required, update - id and changes are required, delete, complete - id is required. Task and changes are validated as in create and update of the task.
+// @Description Mode atomic (default) - all operations are applied or none of them, failed batch has status of the first failed operation and not failed operations have status 424.
+// @Description Mode partial - failed operations are skipped, batch with failed operations has status 207.
+// @Description Whole batch is one operation for POST /undo
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param batch body request.BatchRequest true "Operations"
+// @Success 200 {object} response.BatchResponse
+// @Success 207 {object} response.BatchResponse
+// @Header 200,207 {string} X-Undo-Token "Token of the operation for POST /undo"
+// @Failure 400 {object} response.BatchResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.BatchResponse
+// @Failure 404 {object} response.BatchResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /task/batch [post]
+func (h *Handlers) BatchTasksHandler(w http.ResponseWriter, r *http.Request) {
+	var requestData request.BatchRequest
+
+	err := h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	atomic := requestData.Mode != request.BatchPartial
+
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+
+	batch := response.BatchResponse{Status: http.StatusOK, Results: make([]response.BatchResult, len(requestData.Operations))}
+	var errors request.MultiError
+	err = db.Transaction(func(tx storage.Storage) error {
+		// all operations are applied even in atomic mode, so client gets errors of all failed operations at once
+		for i := range requestData.Operations {
+			task, err := h.batchOperation(tx, user.Id, &requestData.Operations[i])
+			if err == nil {
+				batch.Results[i] = response.BatchResult{Status: http.StatusOK, Task: task}
+				continue
+			}
+
+			status := http.StatusBadRequest
+			if errSql, ok := err.(storage.SqlError); ok {
+				status = errSql.GetCode()
+			}
+			batch.Results[i] = response.BatchResult{Status: status, Error: err.Error()}
+			errors = append(errors, fmt.Errorf("operation %d: %w", i, err))
+		}
+
+		if atomic && len(errors) > 0 {
+			return errors
+		}
+		return nil
+	})
+	if err != nil && len(errors) == 0 {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+
+	applied := 0
+	for i, result := range batch.Results {
+		switch {
+		case result.Error == "" && err != nil:
+			// operation is rolled back with failed batch
+			batch.Results[i] = response.BatchResult{Status: http.StatusFailedDependency, Error: "batch is rolled back"}
+		case result.Error == "":
+			applied++
+		case batch.Status == http.StatusOK:
+			batch.Status = result.Status
+		}
+	}
+	if len(errors) > 0 {
+		batch.Error = errors.Error()
+		if !atomic {
+			batch.Status = http.StatusMultiStatus
+		}
+	}
+
+	if applied > 0 {
+		w.Header().Set(UndoTokenHeader, token)
+	}
+	h.JSON(w, &batch)
+}
+
+// batchOperation validates and applies operation of the batch, returns changed task, nil for deleted task
+func (h *Handlers) batchOperation(tx storage.Storage, userId int, operation *request.BatchOperation) (*storage.Task, error) {
+	if err := operation.ValidateOperation(); err != nil {
+		return nil, err
+	}
+
+	switch operation.Op {
+	case request.BatchCreate:
+		err := operation.Task.ValidateRequest(tagsList.NewTagsMemoryList(&tx, userId, operation.Task.ProjectId, h.Log))
+		if err != nil {
+			return nil, err
+		}
+		return tx.CreateTask(userId, taskCreate(operation.Task))
+	case request.BatchUpdate:
+		// tags of the task are validated against tags of its project
+		task, err := tx.GetTask(userId, operation.Id)
+		if err != nil {
+			return nil, err
+		}
+		err = operation.Changes.ValidateRequest(tagsList.NewTagsMemoryList(&tx, userId, task.ProjectId, h.Log))
+		if err != nil {
+			return nil, err
+		}
+		return tx.UpdateTask(userId, operation.Id, taskUpdate(operation.Changes))
+	case request.BatchComplete:
+		done := true
+		return tx.UpdateTask(userId, operation.Id, &storage.TaskUpdate{Done: &done})
+	default:
+		return nil, tx.DeleteTask(userId, strconv.Itoa(operation.Id))
+	}
+}
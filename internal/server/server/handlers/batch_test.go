@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+func TestBatchTasksHandler(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		wantStatus   int
+		wantStatuses []int
+		wantTexts    []string
+		wantUndo     bool
+	}{
+		{
+			name:         "atomic mode rolls back all operations",
+			mode:         "atomic",
+			wantStatus:   http.StatusBadRequest,
+			wantStatuses: []int{http.StatusFailedDependency, http.StatusBadRequest, http.StatusFailedDependency},
+			wantTexts:    []string{"old"},
+			wantUndo:     false,
+		},
+		{
+			name:         "default mode is atomic",
+			mode:         "",
+			wantStatus:   http.StatusBadRequest,
+			wantStatuses: []int{http.StatusFailedDependency, http.StatusBadRequest, http.StatusFailedDependency},
+			wantTexts:    []string{"old"},
+			wantUndo:     false,
+		},
+		{
+			name:         "partial mode skips failed operation",
+			mode:         "partial",
+			wantStatus:   http.StatusMultiStatus,
+			wantStatuses: []int{http.StatusOK, http.StatusBadRequest, http.StatusOK},
+			wantTexts:    []string{"created"},
+			wantUndo:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, user := newTestHandlers(t)
+			due := time.Now().Add(24 * time.Hour)
+			old, err := h.Db.CreateTask(user.Id, &storage.TaskCreate{Text: "old", Tags: []string{testTag}, Due: &due})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// second operation fails, its tag doesn't exist
+			body := fmt.Sprintf(`{"mode": %q, "operations": [
+				{"op": "create", "task": {"text": "created", "tags": [%q], "due": %q}},
+				{"op": "create", "task": {"text": "failed", "tags": ["unknown"], "due": %q}},
+				{"op": "delete", "id": %d}
+			]}`, tt.mode, testTag, due.Format(time.RFC3339), due.Format(time.RFC3339), old.Id)
+			w := serveTest(h.BatchTasksHandler, user, http.MethodPost, "/task/batch", body)
+
+			var batch response.BatchResponse
+			if err = json.NewDecoder(w.Body).Decode(&batch); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.wantStatus || batch.Status != tt.wantStatus {
+				t.Errorf("status = %d, body status = %d, want %d", w.Code, batch.Status, tt.wantStatus)
+			}
+			statuses := []int{}
+			for _, result := range batch.Results {
+				statuses = append(statuses, result.Status)
+			}
+			if !reflect.DeepEqual(statuses, tt.wantStatuses) {
+				t.Errorf("statuses of operations = %v, want %v", statuses, tt.wantStatuses)
+			}
+			if texts := taskTexts(t, h, user); !reflect.DeepEqual(texts, tt.wantTexts) {
+				t.Errorf("tasks = %v, want %v", texts, tt.wantTexts)
+			}
+			if undo := w.Header().Get(UndoTokenHeader) != ""; undo != tt.wantUndo {
+				t.Errorf("undo token is sent = %v, want %v", undo, tt.wantUndo)
+			}
+		})
+	}
+}
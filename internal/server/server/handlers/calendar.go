@@ -0,0 +1,134 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"web/internal/auth"
+	"web/internal/format"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/server/middleware"
+	"web/internal/storage"
+)
+
+// calendarPath path of calendar feed
+const calendarPath = "/calendar.ics"
+
+// CalendarHandler returns iCalendar feed of tasks with due dates
+// @Summary Calendar feed
+// @Description iCalendar feed of tasks of the token owner for calendar apps, token is created by POST /user/calendar.
+// @Description Tasks are filtered as in GET /task/export, each task is VTODO with due, or VEVENT starting at due with component=event
+// @Tags transfer
+// @Produce text/calendar
+// @Param token query string true "Calendar token"
+// @Param component query string false "Component: todo (default) or event"
+// @Param tag query string false "Tags separated by ','"
+// @Param mode query string false "Tag mode: full or short"
+// @Param project query int false "Project id"
+// @Param assignee query string false "Assignee name"
+// @Param priority query string false "Priorities separated by ',': P0-P4"
+// @Param ready query bool false "Only not done tasks without not done blockers"
+// @Param due_from query string false "Only tasks due at or after this time, format: 2006-01-02T15:04:05Z"
+// @Param due_to query string false "Only tasks due before this time, format: 2006-01-02T15:04:05Z"
+// @Success 200 {file} file
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /calendar.ics [get]
+func (h *Handlers) CalendarHandler(w http.ResponseWriter, r *http.Request) {
+	component, err := icsComponent(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	tasks, err := h.exportTasks(r)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	var feed bytes.Buffer
+	if err = format.WriteICS(&feed, tasks, component); err != nil {
+		h.Log.Error(fmt.Sprintf("handlers.CalendarHandler: %v", err))
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("can't export tasks")))
+		return
+	}
+
+	// url has secret token, so feed is not cached by proxies
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Cache-Control", "private, no-store")
+	if _, err = feed.WriteTo(w); err != nil {
+		h.Log.Error(fmt.Sprintf("handlers.CalendarHandler: %v", err))
+	}
+}
+
+// CreateCalendarTokenHandler creates calendar feed token of the current user
+// @Summary Create calendar token
+// @Description Create secret token of calendar feed GET /calendar.ics?token=<token>, previous token of the user stops working. Token is returned only once
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} response.OkResponse{data=response.CalendarToken}
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /user/calendar [post]
+func (h *Handlers) CreateCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	user := request.UserFromContext(r.Context())
+
+	token, err := auth.NewToken()
+	if err != nil {
+		h.Log.Error(err.Error())
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("internal server error")))
+		return
+	}
+
+	err = h.Db.SetCalendarToken(user.Id, auth.HashToken(token))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("internal server error")))
+		return
+	}
+
+	h.JSON(w, response.OK(response.CalendarToken{
+		Token: token,
+		Url:   fmt.Sprintf("%s?%s=%s", calendarPath, middleware.CalendarTokenParam, token),
+	}))
+}
+
+// DeleteCalendarTokenHandler deletes calendar feed token of the current user
+// @Summary Delete calendar token
+// @Description Delete secret token of calendar feed, feed stops working
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /user/calendar [delete]
+func (h *Handlers) DeleteCalendarTokenHandler(w http.ResponseWriter, r *http.Request) {
+	user := request.UserFromContext(r.Context())
+
+	err := h.Db.DeleteCalendarToken(user.Id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK())
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"net/http"
+	"strconv"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+// GetCommentsHandler returns comments of the task
+// @Summary Get comments
+// @Description Get comments of the task from the oldest one
+// @Tags comments
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Success 200 {object} response.OkResponse{data=storage.Comments}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/comment [get]
+func (h *Handlers) GetCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	comments, err := h.Db.GetComments(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(comments))
+}
+
+// CreateCommentHandler adds comment to the task
+// @Summary Create comment
+// @Description Add comment with markdown text to the task, any user who sees the task can comment it
+// @Tags comments
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param comment body request.CommentRequest true "Comment"
+// @Success 200 {object} response.OkResponse{data=storage.Comment}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/comment [post]
+func (h *Handlers) CreateCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	var requestData request.CommentRequest
+	err = h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	comment, err := h.Db.CreateComment(user.Id, id, requestData.Text)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(comment))
+}
+
+// DeleteCommentHandler deletes comment of the task
+// @Summary Delete comment
+// @Description Delete comment, only author of the comment and owner of the task can delete it
+// @Tags comments
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param comment path int true "Comment id"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/comment/{comment} [delete]
+func (h *Handlers) DeleteCommentHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	commentId, err := strconv.Atoi(chi.URLParam(r, "comment"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	err = h.Db.DeleteComment(user.Id, id, commentId)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK())
+}
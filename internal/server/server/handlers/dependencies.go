@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"net/http"
+	"strconv"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+// AddDependencyHandler adds blocker of the task
+// @Summary Add blocker
+// @Description Task from request body blocks task from path until it is done. Dependency which makes cycle is rejected
+// @Tags dependencies
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Blocked task id"
+// @Param blocker body request.DependencyRequest true "Blocker task"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Router /task/{id}/blocker [post]
+func (h *Handlers) AddDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	var requestData request.DependencyRequest
+	err = h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	err = h.Db.AddDependency(user.Id, requestData.TaskId, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK())
+}
+
+// RemoveDependencyHandler removes blocker of the task
+// @Summary Remove blocker
+// @Description Remove blocker of the task
+// @Tags dependencies
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Blocked task id"
+// @Param blocker path int true "Blocker task id"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/blocker/{blocker} [delete]
+func (h *Handlers) RemoveDependencyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	blockerId, err := strconv.Atoi(chi.URLParam(r, "blocker"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	err = h.Db.RemoveDependency(user.Id, blockerId, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK())
+}
+
+// GetDependencyGraphHandler returns dependency graph of tasks
+// @Summary Get dependency graph
+// @Description Get tasks with dependencies and dependencies between them
+// @Tags dependencies
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param project query int false "Project id"
+// @Param assignee query string false "Assignee name"
+// @Param priority query string false "Priorities separated by ',': P0-P4"
+// @Param sort query string false "Order of tasks: priority (then due), due or position"
+// @Param ready query bool false "Only not done tasks without not done blockers"
+// @Param due_from query string false "Only tasks due at or after this time, format: 2006-01-02T15:04:05Z"
+// @Param due_to query string false "Only tasks due before this time, format: 2006-01-02T15:04:05Z"
+// @Success 200 {object} response.OkResponse{data=storage.DependencyGraph}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /task/graph [get]
+func (h *Handlers) GetDependencyGraphHandler(w http.ResponseWriter, r *http.Request) {
+	user := request.UserFromContext(r.Context())
+
+	filter, err := taskFilter(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	graph, err := h.Db.GetDependencyGraph(user.Id, filter)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+
+	h.JSON(w, response.OK(graph))
+}
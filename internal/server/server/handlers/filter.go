@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"web/internal/storage"
+)
+
+// taskFilter returns filter of task queries from the request query params:
+// project - id of the project, assignee - name of the assignee,
+// priority - priorities separated by ',', sort - order of tasks: priority, due or position,
+// ready - true returns only not done tasks without not done blockers,
+// due_from and due_to - due window in RFC3339 format, due_from is inclusive and due_to is exclusive
+func taskFilter(r *http.Request) (*storage.TaskFilter, error) {
+	var filter storage.TaskFilter
+	var err error
+	query := r.URL.Query()
+
+	filter.ProjectId, err = projectParam(r)
+	if err != nil {
+		return nil, err
+	}
+	filter.Assignee = query.Get("assignee")
+
+	if priorities := query.Get("priority"); priorities != "" {
+		for _, name := range strings.Split(priorities, ",") {
+			priority, err := storage.ParsePriority(name)
+			if err != nil {
+				return nil, err
+			}
+			filter.Priorities = append(filter.Priorities, priority)
+		}
+	}
+
+	switch sort := query.Get("sort"); sort {
+	case "", storage.SortPriority, storage.SortDue, storage.SortPosition:
+		filter.Sort = sort
+	default:
+		return nil, fmt.Errorf("unknown sort '%s', expect one of: %s, %s, %s",
+			sort, storage.SortPriority, storage.SortDue, storage.SortPosition)
+	}
+
+	if ready := query.Get("ready"); ready != "" {
+		filter.Ready, err = strconv.ParseBool(ready)
+		if err != nil {
+			return nil, fmt.Errorf("expect ready as true or false, given: '%s'", ready)
+		}
+	}
+
+	if filter.DueFrom, err = timeParam(r, "due_from"); err != nil {
+		return nil, err
+	}
+	if filter.DueTo, err = timeParam(r, "due_to"); err != nil {
+		return nil, err
+	}
+	if filter.DueFrom != nil && filter.DueTo != nil && !filter.DueFrom.Before(*filter.DueTo) {
+		return nil, fmt.Errorf("expect due_from before due_to")
+	}
+	return &filter, nil
+}
+
+// projectParam returns id of the project from 'project' query param, 0 if param is not set
+func projectParam(r *http.Request) (int, error) {
+	project := r.URL.Query().Get("project")
+	if project == "" {
+		return 0, nil
+	}
+
+	id, err := strconv.Atoi(project)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("expect project id as positive integer, given: '%s'", project)
+	}
+	return id, nil
+}
+
+// maxAuditLimit max number of audit entries in one response
+const maxAuditLimit = 1000
+
+// auditFilter returns filter of audit log from the request query params:
+// actor - name of the user, entity - task, tag, project, comment, attachment, checklist_item, dependency or share,
+// entity_id - id of the entity, task - id of the task, from and to - time range in RFC3339 format, to is excluded,
+// limit - max number of the newest entries
+func auditFilter(r *http.Request) (*storage.AuditFilter, error) {
+	var filter storage.AuditFilter
+	var err error
+	query := r.URL.Query()
+
+	filter.Actor = query.Get("actor")
+	filter.Entity = query.Get("entity")
+	if filter.EntityId, err = intParam(r, "entity_id"); err != nil {
+		return nil, err
+	}
+	if filter.TaskId, err = intParam(r, "task"); err != nil {
+		return nil, err
+	}
+	if filter.Limit, err = intParam(r, "limit"); err != nil {
+		return nil, err
+	}
+	if filter.Limit > maxAuditLimit {
+		return nil, fmt.Errorf("expect limit up to %d, given: %d", maxAuditLimit, filter.Limit)
+	}
+	if filter.From, err = timeParam(r, "from"); err != nil {
+		return nil, err
+	}
+	if filter.To, err = timeParam(r, "to"); err != nil {
+		return nil, err
+	}
+	return &filter, nil
+}
+
+// intParam returns positive integer query param, 0 if param is not set
+func intParam(r *http.Request, name string) (int, error) {
+	text := r.URL.Query().Get(name)
+	if text == "" {
+		return 0, nil
+	}
+
+	value, err := strconv.Atoi(text)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("expect %s as positive integer, given: '%s'", name, text)
+	}
+	return value, nil
+}
+
+// timeParam returns query param in RFC3339 format, nil if param is not set
+func timeParam(r *http.Request, name string) (*time.Time, error) {
+	text := r.URL.Query().Get(name)
+	if text == "" {
+		return nil, nil
+	}
+
+	value, err := time.Parse(time.RFC3339, text)
+	if err != nil {
+		return nil, fmt.Errorf("expect %s in RFC3339 format, given: '%s'", name, text)
+	}
+	return &value, nil
+}
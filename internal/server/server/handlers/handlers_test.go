@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"web/internal/config"
+	"web/internal/server/context/request"
+	"web/internal/server/server"
+	"web/internal/storage"
+	"web/internal/storage/sqlite"
+)
+
+// testTag personal tag of the test user
+const testTag = "work"
+
+// newTestHandlers returns handlers with migrated sqlite database in temporary directory of the test
+// and user with personal testTag
+func newTestHandlers(t *testing.T) (*Handlers, *storage.User) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.DatabaseConfig.Config = map[string]string{"storagePath": filepath.Join(t.TempDir(), "test.db")}
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	db := (&sqlite.StoreSqlite{}).Connect(cfg, log)
+	t.Cleanup(func() { _ = db.Close() })
+
+	user, err := db.CreateUser("alice", "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.CreateTag(user.Id, 0, testTag); err != nil {
+		t.Fatal(err)
+	}
+	return NewHandlers(&server.Server{Db: db, Log: log, Cfg: cfg}), user
+}
+
+// serveTest calls handler with request of the user
+func serveTest(handler http.HandlerFunc, user *storage.User, method, target, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r = r.WithContext(request.WithUser(r.Context(), user))
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+// taskTexts returns texts of all tasks of the user
+func taskTexts(t *testing.T, h *Handlers, user *storage.User) []string {
+	t.Helper()
+
+	tasks, err := h.Db.GetAllTasks(user.Id, &storage.TaskFilter{Sort: storage.SortPosition})
+	if err != nil {
+		t.Fatal(err)
+	}
+	texts := []string{}
+	for _, task := range tasks.Tasks {
+		texts = append(texts, task.Text)
+	}
+	return texts
+}
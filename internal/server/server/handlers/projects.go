@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"net/http"
+	"strconv"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+// GetProjectsHandler returns all projects of the user
+// @Summary Get projects
+// @Description Get own projects of the user and projects shared with him
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} response.OkResponse{data=storage.Projects}
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /project/ [get]
+func (h *Handlers) GetProjectsHandler(w http.ResponseWriter, r *http.Request) {
+	user := request.UserFromContext(r.Context())
+
+	projects, err := h.Db.GetProjects(user.Id)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+
+	h.JSON(w, response.OK(projects))
+}
+
+// GetProjectHandler returns project by id
+// @Summary Get project by id
+// @Description Get project by id
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Project id"
+// @Success 200 {object} response.OkResponse{data=storage.Project}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /project/{id} [get]
+func (h *Handlers) GetProjectHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	project, err := h.Db.GetProject(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(project))
+}
+
+// CreateProjectHandler creates new project
+// @Summary Create new project
+// @Description Create new project with uniq name, project has own tags
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param project body request.ProjectRequest true "Project"
+// @Success 200 {object} response.OkResponse{data=storage.Project}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Router /project/ [post]
+func (h *Handlers) CreateProjectHandler(w http.ResponseWriter, r *http.Request) {
+	var requestData request.ProjectRequest
+
+	err := h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	project, err := h.Db.CreateProject(user.Id, requestData.Name)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(project))
+}
+
+// UpdateProjectHandler renames project
+// @Summary Rename project
+// @Description Rename project
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Project id"
+// @Param project body request.ProjectRequest true "Project"
+// @Success 200 {object} response.OkResponse{data=storage.Project}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Router /project/{id} [put]
+func (h *Handlers) UpdateProjectHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	var requestData request.ProjectRequest
+	err = h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	project, err := h.Db.RenameProject(user.Id, id, requestData.Name)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(project))
+}
+
+// DeleteProjectHandler deletes project without tasks and tags
+// @Summary Delete project
+// @Description Delete project and its shares. Project must have no tasks and tags: move them to trash and empty trash first
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Project id"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Router /project/{id} [delete]
+func (h *Handlers) DeleteProjectHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	err = h.Db.DeleteProject(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK())
+}
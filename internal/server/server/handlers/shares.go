@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"net/http"
+	"strconv"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+// ShareTaskHandler shares task with the user
+// @Summary Share task
+// @Description Share own task with the user, permission: read or write. Existing share of the user is replaced
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param share body request.ShareRequest true "Share"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/share [post]
+func (h *Handlers) ShareTaskHandler(w http.ResponseWriter, r *http.Request) {
+	h.share(w, r, h.Db.ShareTask)
+}
+
+// UnshareTaskHandler revokes share of the task
+// @Summary Unshare task
+// @Description Revoke share of own task from the user
+// @Tags shares
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param name path string true "User name"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/share/{name} [delete]
+func (h *Handlers) UnshareTaskHandler(w http.ResponseWriter, r *http.Request) {
+	h.unshare(w, r, h.Db.UnshareTask)
+}
+
+// GetTaskSharesHandler returns shares of the task
+// @Summary Get task shares
+// @Description Get users own task is shared with
+// @Tags shares
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Success 200 {object} response.OkResponse{data=storage.Shares}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/share [get]
+func (h *Handlers) GetTaskSharesHandler(w http.ResponseWriter, r *http.Request) {
+	h.getShares(w, r, h.Db.GetTaskShares)
+}
+
+// ShareProjectHandler shares project with the user
+// @Summary Share project
+// @Description Share own project and all its tasks with the user, permission: read or write. Existing share of the user is replaced
+// @Tags shares
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Project id"
+// @Param share body request.ShareRequest true "Share"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /project/{id}/share [post]
+func (h *Handlers) ShareProjectHandler(w http.ResponseWriter, r *http.Request) {
+	h.share(w, r, h.Db.ShareProject)
+}
+
+// UnshareProjectHandler revokes share of the project
+// @Summary Unshare project
+// @Description Revoke share of own project from the user
+// @Tags shares
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Project id"
+// @Param name path string true "User name"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /project/{id}/share/{name} [delete]
+func (h *Handlers) UnshareProjectHandler(w http.ResponseWriter, r *http.Request) {
+	h.unshare(w, r, h.Db.UnshareProject)
+}
+
+// GetProjectSharesHandler returns shares of the project
+// @Summary Get project shares
+// @Description Get users own project is shared with
+// @Tags shares
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Project id"
+// @Success 200 {object} response.OkResponse{data=storage.Shares}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /project/{id}/share [get]
+func (h *Handlers) GetProjectSharesHandler(w http.ResponseWriter, r *http.Request) {
+	h.getShares(w, r, h.Db.GetProjectShares)
+}
+
+// share shares task or project from id url param with storage method
+func (h *Handlers) share(w http.ResponseWriter, r *http.Request, share func(userId, id int, name, permission string) error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	var requestData request.ShareRequest
+	err = h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	err = share(user.Id, id, requestData.User, requestData.Permission)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK())
+}
+
+// unshare revokes share of task or project from id url param with storage method
+func (h *Handlers) unshare(w http.ResponseWriter, r *http.Request, unshare func(userId, id int, name string) error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	err = unshare(user.Id, id, chi.URLParam(r, "name"))
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK())
+}
+
+// getShares returns shares of task or project from id url param with storage method
+func (h *Handlers) getShares(w http.ResponseWriter, r *http.Request, getShares func(userId, id int) (*storage.Shares, error)) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	shares, err := getShares(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(shares))
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"net/http"
+	"strconv"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+// GetTaskTreeHandler returns task with its subtasks and checklists
+// @Summary Get task tree
+// @Description Get task with its checklist and subtasks of any depth
+// @Tags subtasks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Success 200 {object} response.OkResponse{data=storage.TaskTree}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /task/{id}/tree [get]
+func (h *Handlers) GetTaskTreeHandler(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.getTaskTree(r)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(tree))
+}
+
+// GetTaskProgressHandler returns progress of the task
+// @Summary Get task progress
+// @Description Get done part of subtasks and checklist items of all levels, task without them is 100% done if it is done
+// @Tags subtasks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Success 200 {object} response.OkResponse{data=storage.Progress}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /task/{id}/progress [get]
+func (h *Handlers) GetTaskProgressHandler(w http.ResponseWriter, r *http.Request) {
+	tree, err := h.getTaskTree(r)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(tree.Progress()))
+}
+
+// getTaskTree returns tree of the task from id url param
+func (h *Handlers) getTaskTree(r *http.Request) (*storage.TaskTree, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return nil, err
+	}
+	user := request.UserFromContext(r.Context())
+
+	return h.Db.GetTaskTree(user.Id, id)
+}
+
+// GetChecklistHandler returns checklist of the task
+// @Summary Get checklist
+// @Description Get checklist items of the task
+// @Tags subtasks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Success 200 {object} response.OkResponse{data=storage.Checklist}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/checklist [get]
+func (h *Handlers) GetChecklistHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	checklist, err := h.Db.GetChecklist(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(checklist))
+}
+
+// CreateChecklistItemHandler adds item to the checklist of the task
+// @Summary Create checklist item
+// @Description Add item to the checklist of the task, text is required
+// @Tags subtasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param checklist_item body request.ChecklistItemRequest true "Checklist item"
+// @Success 200 {object} response.OkResponse{data=storage.ChecklistItem}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/checklist [post]
+func (h *Handlers) CreateChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	var requestData request.ChecklistItemRequest
+	err = h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest(true)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	item, err := h.Db.CreateChecklistItem(user.Id, id, *requestData.Text)
+	if err == nil && requestData.Done != nil && *requestData.Done {
+		item, err = h.Db.UpdateChecklistItem(user.Id, id, item.Id, nil, requestData.Done)
+	}
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(item))
+}
+
+// UpdateChecklistItemHandler changes checklist item
+// @Summary Update checklist item
+// @Description Change text or done flag of the checklist item, missing fields are not changed
+// @Tags subtasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param item path int true "Checklist item id"
+// @Param checklist_item body request.ChecklistItemRequest true "Checklist item"
+// @Success 200 {object} response.OkResponse{data=storage.ChecklistItem}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/checklist/{item} [patch]
+func (h *Handlers) UpdateChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	itemId, err := strconv.Atoi(chi.URLParam(r, "item"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	var requestData request.ChecklistItemRequest
+	err = h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest(false)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	item, err := h.Db.UpdateChecklistItem(user.Id, id, itemId, requestData.Text, requestData.Done)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(item))
+}
+
+// DeleteChecklistItemHandler deletes checklist item
+// @Summary Delete checklist item
+// @Description Delete item from the checklist of the task
+// @Tags subtasks
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param item path int true "Checklist item id"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /task/{id}/checklist/{item} [delete]
+func (h *Handlers) DeleteChecklistItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	itemId, err := strconv.Atoi(chi.URLParam(r, "item"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	err = h.Db.DeleteChecklistItem(user.Id, id, itemId)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK())
+}
@@ -14,13 +14,26 @@ import (
 // @Tags tags
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Success 200 {object} response.OkResponse{data=storage.Tags}
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
+// @Param project query int false "Project id, personal tags if not set"
 // @Router /tag [get]
 func (h *Handlers) GetTagsHandler(w http.ResponseWriter, req *http.Request) {
-	Tags, err := h.Db.GetAllTags()
+	user := request.UserFromContext(req.Context())
+
+	projectId, err := projectParam(req)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	Tags, err := h.Db.GetAllTags(user.Id, projectId)
 	if err != nil {
 		h.JSON(w, response.Error(http.StatusBadRequest, err))
 		return
@@ -35,16 +48,28 @@ func (h *Handlers) GetTagsHandler(w http.ResponseWriter, req *http.Request) {
 // @Tags tags
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param name path string true "Tag name"
 // @Success 200 {object} response.OkResponse{data=storage.Tag}
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
+// @Param project query int false "Project id, personal tags if not set"
 // @Router /tag/{name} [get]
 func (h *Handlers) GetTagHandler(w http.ResponseWriter, req *http.Request) {
+	user := request.UserFromContext(req.Context())
 	tagName := chi.URLParam(req, "name")
 
-	tag, err := h.Db.GetTag(tagName)
+	projectId, err := projectParam(req)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	tag, err := h.Db.GetTag(user.Id, projectId, tagName)
 	if err != nil {
 		h.JSON(w, response.Error(http.StatusBadRequest, err))
 		return
@@ -59,9 +84,14 @@ func (h *Handlers) GetTagHandler(w http.ResponseWriter, req *http.Request) {
 // @Tags tags
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param tag body request.TagRequest true "Tag name"
 // @Success 200 {object} response.OkResponse
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /tag [post]
 func (h *Handlers) CreateTagHandler(w http.ResponseWriter, r *http.Request) {
@@ -79,7 +109,13 @@ func (h *Handlers) CreateTagHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.Db.CreateTag(requestData.Name)
+	user := request.UserFromContext(r.Context())
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+	err = db.CreateTag(user.Id, requestData.ProjectId, requestData.Name)
 	if err != nil {
 		switch errSql := err.(type) {
 		case storage.SqlError:
@@ -90,25 +126,44 @@ func (h *Handlers) CreateTagHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set(UndoTokenHeader, token)
 	h.JSON(w, response.OK())
 }
 
 // DeleteTagHandler deletes tag by name
 // @Summary Delete tag by name
-// @Description Delete tag
+// @Description Move tag to trash
 // @Tags tags
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param name path string true "Tag name"
 // @Success 200 {object} response.OkResponseEmpty
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
+// @Param project query int false "Project id, personal tags if not set"
 // @Router /tag/{name} [delete]
 func (h *Handlers) DeleteTagHandler(w http.ResponseWriter, r *http.Request) {
+	user := request.UserFromContext(r.Context())
 	tagName := chi.URLParam(r, "name")
 
-	err := h.Db.DeleteTag(tagName)
+	projectId, err := projectParam(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+	err = db.DeleteTag(user.Id, projectId, tagName)
 	if err != nil {
 		switch errSql := err.(type) {
 		case storage.SqlError:
@@ -119,22 +174,42 @@ func (h *Handlers) DeleteTagHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set(UndoTokenHeader, token)
 	h.JSON(w, response.OK())
 }
 
 // DeleteTagsHandler deletes all tags
 // @Summary Delete tags
-// @Description Delete tags
+// @Description Move all tags of the project to trash
 // @Tags tags
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Success 200 {object} response.OkResponseEmpty
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
+// @Param project query int false "Project id, personal tags if not set"
 // @Router /tag/ [delete]
 func (h *Handlers) DeleteTagsHandler(w http.ResponseWriter, r *http.Request) {
-	err := h.Db.DeleteTag()
+	user := request.UserFromContext(r.Context())
+
+	projectId, err := projectParam(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+	err = db.DeleteTag(user.Id, projectId)
 	if err != nil {
 		switch errSql := err.(type) {
 		case storage.SqlError:
@@ -145,5 +220,6 @@ func (h *Handlers) DeleteTagsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set(UndoTokenHeader, token)
 	h.JSON(w, response.OK())
 }
@@ -9,6 +9,7 @@ import (
 	"web/internal/server/context/request"
 	"web/internal/server/context/response"
 	"web/internal/storage"
+	tagsList "web/storage/tags-list"
 )
 
 // Todo get by due date
@@ -20,9 +21,13 @@ import (
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Task id"
 // @Success 200 {object} response.OkResponse{data=storage.Task}
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /task/{id} [get]
@@ -35,8 +40,10 @@ func (h *Handlers) GetTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	user := request.UserFromContext(r.Context())
+
 	// Todo check using method by due date
-	task, err := h.Db.GetTask(idInt)
+	task, err := h.Db.GetTask(user.Id, idInt)
 	if err != nil {
 		switch errSql := err.(type) {
 		case storage.SqlError:
@@ -56,14 +63,33 @@ func (h *Handlers) GetTaskHandler(w http.ResponseWriter, r *http.Request) {
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Success 200 {object} response.OkResponse{data=storage.Tasks} "Successful response"
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
+// @Param project query int false "Project id"
+// @Param assignee query string false "Assignee name"
+// @Param priority query string false "Priorities separated by ',': P0-P4"
+// @Param sort query string false "Order of tasks: priority (then due), due or position"
+// @Param ready query bool false "Only not done tasks without not done blockers"
+// @Param due_from query string false "Only tasks due at or after this time, format: 2006-01-02T15:04:05Z"
+// @Param due_to query string false "Only tasks due before this time, format: 2006-01-02T15:04:05Z"
 // @Router /task/ [get]
 // Context from Function internal/server/server/handlers/task.go:handlers.*Handlers.GetTasksHandler
 func (h *Handlers) GetTasksHandler(w http.ResponseWriter, r *http.Request) {
-	allTasks, err := h.Db.GetAllTasks()
+	user := request.UserFromContext(r.Context())
+
+	filter, err := taskFilter(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	allTasks, err := h.Db.GetAllTasks(user.Id, filter)
 
 	if err != nil {
 		switch errSql := err.(type) {
@@ -80,13 +106,19 @@ func (h *Handlers) GetTasksHandler(w http.ResponseWriter, r *http.Request) {
 
 // CreateTaskHandler creates new task
 // @Summary Create new task
-// @Description "Create new task object with the following fields: text (string, required) - text of the task, tags ([]string, required) - tags associated with the task, due (string, required) - due date of the task in '2006-01-02T15:04:05Z' format"
+// @Description "Create new task object with the following fields: text (string, required) - text of the task, tags ([]string, required) - tags associated with the task, due (string, required) - due date of the task in '2006-01-02T15:04:05Z' format, project_id (int, optional) - project of the task, tags are taken from this project, assignee (string, optional) - name of the user the task is assigned to, priority (string, optional) - P0 (the most important) - P4, P2 by default, parent_id (int, optional) - parent task in the same project"
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param task body request.TaskRequest true "Task"
-// @Success 200 {object} response.OkResponseEmpty
+// @Success 200 {object} response.OkResponse{data=storage.Task}
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /task/ [post]
 // Context from Function internal/server/server/handlers/task.go:handlers.*Handlers.CreateTaskHandler
@@ -99,44 +131,241 @@ func (h *Handlers) CreateTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	user := request.UserFromContext(r.Context())
+
 	// Todo refactor all validate request
-	err = requestData.ValidateRequest(h.AllTags)
+	err = requestData.ValidateRequest(tagsList.NewTagsMemoryList(&h.Db, user.Id, requestData.ProjectId, h.Log))
 	if err != nil {
 		h.JSON(w, response.Error(http.StatusBadRequest, err))
 		return
 	}
-	// reqData
-	// Todo why reqData param doing cycle import
-	// Todo remove this and mak it more beautiful
-	dueDate, _ := time.Parse(time.RFC3339, requestData.Due)
-	err = h.Db.CreateTask(requestData.Text, requestData.Tags, &dueDate)
+
+	db, token, err := h.undoable()
 	if err != nil {
-		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+	task, err := db.CreateTask(user.Id, taskCreate(&requestData))
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
 		return
 	}
 
-	h.JSON(w, response.OK())
+	w.Header().Set(UndoTokenHeader, token)
+	h.JSON(w, response.OK(task))
 }
 
+// UpdateTaskHandler changes fields of the task
+// @Summary Update task
+// @Description Change fields of the task, missing fields are not changed. Tags are taken from the project of the task. Empty assignee removes assignee, parent_id = 0 moves task to the top level. Owner, assignee and users with write share can update task
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param task body request.TaskUpdateRequest true "Task fields"
+// @Success 200 {object} response.OkResponse{data=storage.Task}
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /task/{id} [patch]
 func (h *Handlers) UpdateTaskHandler(w http.ResponseWriter, r *http.Request) {
-	///
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	var requestData request.TaskUpdateRequest
+	err = h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+
+	// tags of the task are validated against tags of its project
+	task, err := h.Db.GetTask(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	err = requestData.ValidateRequest(tagsList.NewTagsMemoryList(&h.Db, user.Id, task.ProjectId, h.Log))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+	task, err = db.UpdateTask(user.Id, id, taskUpdate(&requestData))
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	w.Header().Set(UndoTokenHeader, token)
+	h.JSON(w, response.OK(task))
+}
+
+// MoveTaskHandler moves task in user defined order
+// @Summary Move task
+// @Description Place task right before or right after other task in user defined order, use sort=position to get tasks in this order. Order is shared by all views, so task is next to other task in any project or tag view containing both
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Param move body request.MoveRequest true "Other task"
+// @Success 200 {object} response.OkResponse{data=storage.Task}
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /task/{id}/move [post]
+func (h *Handlers) MoveTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	var requestData request.MoveRequest
+	err = h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	anchorId, after := requestData.Before, false
+	if requestData.After != 0 {
+		anchorId, after = requestData.After, true
+	}
+
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+	task, err := db.MoveTask(user.Id, id, anchorId, after)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	w.Header().Set(UndoTokenHeader, token)
+	h.JSON(w, response.OK(task))
+}
+
+// GetAssignedTasksHandler returns tasks assigned to the current user
+// @Summary Get tasks assigned to me
+// @Description Get tasks assigned to the current user across all projects
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param project query int false "Project id"
+// @Param priority query string false "Priorities separated by ',': P0-P4"
+// @Param sort query string false "Order of tasks: priority (then due), due or position"
+// @Param ready query bool false "Only not done tasks without not done blockers"
+// @Param due_from query string false "Only tasks due at or after this time, format: 2006-01-02T15:04:05Z"
+// @Param due_to query string false "Only tasks due before this time, format: 2006-01-02T15:04:05Z"
+// @Success 200 {object} response.OkResponse{data=storage.Tasks}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /task/assigned [get]
+func (h *Handlers) GetAssignedTasksHandler(w http.ResponseWriter, r *http.Request) {
+	user := request.UserFromContext(r.Context())
+
+	filter, err := taskFilter(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	filter.Assignee = user.Name
+
+	tasks, err := h.Db.GetAllTasks(user.Id, filter)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(tasks))
 }
 
 // DeleteTasksHandler deletes all tasks
 // @Summary Delete tasks
-// @Description Delete tasks
+// @Description Move all own tasks to trash
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Success 200 {object} response.OkResponseEmpty
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /task/ [delete]
 // Context from Function internal/server/server/handlers/task.go:handlers.*Handlers.DeleteTasksHandler
 func (h *Handlers) DeleteTasksHandler(w http.ResponseWriter, r *http.Request) {
-	// Todo check admin rules
+	user := request.UserFromContext(r.Context())
 
-	err := h.Db.DeleteTask()
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+	err = db.DeleteTask(user.Id)
 	if err != nil {
 		switch errSql := err.(type) {
 		case storage.SqlError:
@@ -147,26 +376,39 @@ func (h *Handlers) DeleteTasksHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set(UndoTokenHeader, token)
 	h.JSON(w, response.OK())
 }
 
 // DeleteTaskHandler deletes task by id
 // @Summary Delete task
-// @Description Delete task
+// @Description Move task to trash, subtasks are moved to the parent of the task. Owner and users with write share can delete task.
+// @Description Task with its checklist, comments and attachments can be restored from trash until trash is purged
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param id path int true "Task ID"
 // @Success 200 {object} response.OkResponseEmpty
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 404 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /task/{id} [delete]
 // Context from Function internal/server/server/handlers/task.go:handlers.*Handlers.DeleteTaskHandler
 func (h *Handlers) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
+	user := request.UserFromContext(r.Context())
 	id := chi.URLParam(r, "id")
 
-	err := h.Db.DeleteTask(id)
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+	err = db.DeleteTask(user.Id, id)
 	if err != nil {
 		switch errSql := err.(type) {
 		case storage.SqlError:
@@ -177,6 +419,7 @@ func (h *Handlers) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set(UndoTokenHeader, token)
 	h.JSON(w, response.OK())
 }
 
@@ -186,10 +429,21 @@ func (h *Handlers) DeleteTaskHandler(w http.ResponseWriter, r *http.Request) {
 // @Tags tasks
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param due path string true "Due date"
 // @Success 200 {object} response.OkResponse{data=storage.Tasks}
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
+// @Param project query int false "Project id"
+// @Param assignee query string false "Assignee name"
+// @Param priority query string false "Priorities separated by ',': P0-P4"
+// @Param sort query string false "Order of tasks: priority (then due), due or position"
+// @Param ready query bool false "Only not done tasks without not done blockers"
+// @Param due_from query string false "Only tasks due at or after this time, format: 2006-01-02T15:04:05Z"
+// @Param due_to query string false "Only tasks due before this time, format: 2006-01-02T15:04:05Z"
 // @Router /task/{due} [get]
 // Context from Function internal/server/server/handlers/task.go:handlers.*Handlers.GetTasksByDueDateHandler
 func (h *Handlers) GetTasksByDueDateHandler(w http.ResponseWriter, r *http.Request) {
@@ -204,7 +458,14 @@ func (h *Handlers) GetTasksByDueDateHandler(w http.ResponseWriter, r *http.Reque
 	}
 	// Todo remove this and mak it more beautiful
 	dueDate, _ := time.Parse(time.RFC3339, due)
-	tasks, err := h.Db.GetTasksByDueDate(&dueDate)
+	filter, err := taskFilter(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	tasks, err := h.Db.GetTasksByDueDate(user.Id, &dueDate, filter)
 	if err != nil {
 		switch errSql := err.(type) {
 		case storage.SqlError:
@@ -217,3 +478,42 @@ func (h *Handlers) GetTasksByDueDateHandler(w http.ResponseWriter, r *http.Reque
 
 	h.JSON(w, response.OK(tasks))
 }
+
+// taskCreate returns new task from validated request
+func taskCreate(requestData *request.TaskRequest) *storage.TaskCreate {
+	dueDate, _ := time.Parse(time.RFC3339, requestData.Due)
+	priority := storage.DefaultPriority
+	if requestData.Priority != "" {
+		priority, _ = storage.ParsePriority(requestData.Priority)
+	}
+
+	return &storage.TaskCreate{
+		ProjectId: requestData.ProjectId,
+		Text:      requestData.Text,
+		Tags:      requestData.Tags,
+		Due:       &dueDate,
+		Assignee:  requestData.Assignee,
+		Priority:  priority,
+		ParentId:  requestData.ParentId,
+	}
+}
+
+// taskUpdate returns changes of the task from validated request
+func taskUpdate(requestData *request.TaskUpdateRequest) *storage.TaskUpdate {
+	update := storage.TaskUpdate{
+		Text:     requestData.Text,
+		Tags:     requestData.Tags,
+		Assignee: requestData.Assignee,
+		ParentId: requestData.ParentId,
+		Done:     requestData.Done,
+	}
+	if requestData.Due != nil {
+		dueDate, _ := time.Parse(time.RFC3339, *requestData.Due)
+		update.Due = &dueDate
+	}
+	if requestData.Priority != nil {
+		priority, _ := storage.ParsePriority(*requestData.Priority)
+		update.Priority = &priority
+	}
+	return &update
+}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"github.com/go-chi/chi"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
+	"web/internal/server/context/request"
 	"web/internal/server/context/response"
 	"web/internal/storage"
+	tagsList "web/storage/tags-list"
 )
 
 // GetTasksByTagHandler returns tasks that have one of the specified tags from the query
@@ -16,39 +19,26 @@ import (
 // @Tags tasks_tags
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param tag query string true "Tags"
 // @Param due query string false "Due"
+// @Param project query int false "Project id, tags are validated against tags of this project"
+// @Param assignee query string false "Assignee name"
+// @Param priority query string false "Priorities separated by ',': P0-P4"
+// @Param sort query string false "Order of tasks: priority (then due), due or position"
+// @Param ready query bool false "Only not done tasks without not done blockers"
+// @Param due_from query string false "Only tasks due at or after this time, format: 2006-01-02T15:04:05Z"
+// @Param due_to query string false "Only tasks due before this time, format: 2006-01-02T15:04:05Z"
 // @Success 200 {object} response.OkResponse{data=storage.Tasks}
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /task/tag/ [get]
 // Context from Function internal/server/server/handlers/task.go:handlers.*Handlers.GetTasksByTagOrByTagAndDueHandler
 func (h *Handlers) GetTasksByTagHandler(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query()
-	tagList := strings.Split(query.Get("tag"), ",")
-	due := query.Get("due")
-
-	var tasks *storage.Tasks
-	var err error
-
-	switch due {
-	case "":
-		err = validateTags(tagList, h.AllTags)
-		if err != nil {
-			h.JSON(w, response.Error(http.StatusBadRequest, err))
-			return
-		}
-		tasks, err = h.Db.GetTasksByTag(tagList)
-	default:
-		err = validateTagsAndDue(tagList, due, h.AllTags)
-		if err != nil {
-			h.JSON(w, response.Error(http.StatusBadRequest, err))
-			return
-		}
-
-		dueDate, _ := time.Parse(time.RFC3339, due)
-		tasks, err = h.Db.GetTasksByTagAndDue(tagList, &dueDate)
-	}
+	tasks, err := h.tasksByTag(h.Db, r, tagModeAny)
 	if err != nil {
 		h.JSON(w, response.Error(http.StatusBadRequest, err))
 		return
@@ -63,11 +53,22 @@ func (h *Handlers) GetTasksByTagHandler(w http.ResponseWriter, r *http.Request)
 // @Tags tasks_tags
 // @Accept json
 // @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Param mode path string true "Mode"
 // @Param tag query string true "Tags"
 // @Param due query string false "Due"
+// @Param project query int false "Project id, tags are validated against tags of this project"
+// @Param assignee query string false "Assignee name"
+// @Param priority query string false "Priorities separated by ',': P0-P4"
+// @Param sort query string false "Order of tasks: priority (then due), due or position"
+// @Param ready query bool false "Only not done tasks without not done blockers"
+// @Param due_from query string false "Only tasks due at or after this time, format: 2006-01-02T15:04:05Z"
+// @Param due_to query string false "Only tasks due before this time, format: 2006-01-02T15:04:05Z"
 // @Success 200 {object} response.OkResponse{data=storage.Tasks}
 // @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
 // @Failure 500 {object} response.ErrorResponse
 // @Router /task/tag/{mode}/ [get]
 // Context from Function internal/server/server/handlers/task.go:handlers.*Handlers.GetTasksByModeAndTagHandler
@@ -78,10 +79,8 @@ func (h *Handlers) GetTasksByModeAndTagHandler(w http.ResponseWriter, r *http.Re
 	var err error
 
 	switch mode {
-	case "full":
-		tasks, err = h.getTasksFull(r)
-	case "short":
-		tasks, err = h.getTasksShort(r)
+	case tagModeFull, tagModeShort:
+		tasks, err = h.tasksByTag(h.Db, r, mode)
 	default:
 		h.JSON(w, response.Error(http.StatusBadRequest, fmt.Errorf("expect mode == full or short, got %v", mode)))
 		return
@@ -100,47 +99,207 @@ func (h *Handlers) GetTasksByModeAndTagHandler(w http.ResponseWriter, r *http.Re
 	h.JSON(w, response.OK(tasks))
 }
 
-func (h *Handlers) getTasksFull(r *http.Request) (*storage.Tasks, error) {
-	query := r.URL.Query()
-	tagList := strings.Split(query.Get("tag"), ",")
-	due := query.Get("due")
+// BulkTagHandler adds and removes tags of tasks matching filter
+// @Summary Bulk tag change
+// @Description Add and remove tags of all tasks matching filter in one transaction, filter is the same as in GET /task/tag/ and GET /task/tag/{mode}/.
+// @Description Mode: empty - tasks with any of tags, "full" - tasks with all tags, "short" - tasks with only all tags. Tags to add must exist in the project of each task, task can't lose all tags.
+// @Description Returns number of changed tasks. Dry run changes nothing, returns all matching tasks and number of tasks to change
+// @Tags tasks_tags
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param mode path string false "Mode: full or short"
+// @Param tag query string true "Tags"
+// @Param due query string false "Due"
+// @Param project query int false "Project id, tags are validated against tags of this project"
+// @Param assignee query string false "Assignee name"
+// @Param priority query string false "Priorities separated by ',': P0-P4"
+// @Param ready query bool false "Only not done tasks without not done blockers"
+// @Param due_from query string false "Only tasks due at or after this time, format: 2006-01-02T15:04:05Z"
+// @Param due_to query string false "Only tasks due before this time, format: 2006-01-02T15:04:05Z"
+// @Param tags body request.BulkTagRequest true "Tags to add and remove"
+// @Success 200 {object} response.OkResponse{data=response.BulkTagResult}
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /task/tag/bulk [post]
+// @Router /task/tag/{mode}/bulk [post]
+func (h *Handlers) BulkTagHandler(w http.ResponseWriter, r *http.Request) {
+	mode := chi.URLParam(r, "mode")
 
-	switch {
-	case due == "":
-		err := validateTags(tagList, h.AllTags)
-		if err != nil {
-			return nil, err
+	var requestData request.BulkTagRequest
+	err := h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	// tags of the filter are checked before transaction is started
+	if _, err = queryTags(r); err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+
+	var result response.BulkTagResult
+	err = db.Transaction(func(tx storage.Storage) error {
+		tasks, err := h.tasksByTag(tx, r, mode)
+		if errSql, ok := err.(storage.SqlError); ok && errSql.GetCode() == http.StatusNotFound {
+			// no matching tasks
+			return nil
 		}
-		return h.Db.GetTasksByTagFull(tagList)
-	default:
-		err := validateTagsAndDue(tagList, due, h.AllTags)
-		deuDate, _ := time.Parse(time.RFC3339, due)
 		if err != nil {
-			return nil, err
+			return err
+		}
+
+		// tags of each project are loaded once
+		projectTags := make(map[int]*tagsList.TagsList)
+		for _, task := range tasks.Tasks {
+			tags, changed := retag(task.Tags, requestData.Add, requestData.Remove)
+			if !changed {
+				continue
+			}
+			if len(tags) == 0 {
+				return fmt.Errorf("task %d can't lose all tags", task.Id)
+			}
+			allTags, ok := projectTags[task.ProjectId]
+			if !ok {
+				allTags = tagsList.NewTagsMemoryList(&tx, user.Id, task.ProjectId, h.Log)
+				projectTags[task.ProjectId] = allTags
+			}
+			for _, tag := range requestData.Add {
+				if !(*allTags)[tag] {
+					return fmt.Errorf("tag '%s' not found in project of task %d", tag, task.Id)
+				}
+			}
+
+			result.Count++
+			if requestData.DryRun {
+				continue
+			}
+			if _, err = tx.UpdateTask(user.Id, task.Id, &storage.TaskUpdate{Tags: tags}); err != nil {
+				return err
+			}
+		}
+
+		if requestData.DryRun {
+			result.Tasks = tasks.Tasks
 		}
-		return h.Db.GetTasksByDueAndTagFull(tagList, &deuDate)
+		return nil
+	})
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
 	}
 
+	if !requestData.DryRun && result.Count > 0 {
+		w.Header().Set(UndoTokenHeader, token)
+	}
+	h.JSON(w, response.OK(result))
 }
 
-func (h *Handlers) getTasksShort(r *http.Request) (*storage.Tasks, error) {
-	query := r.URL.Query()
-	tagList := strings.Split(query.Get("tag"), ",")
-	due := query.Get("due")
+// retag returns tags with added tags and without removed tags, false if tags are not changed
+func retag(tags, add, remove []string) ([]string, bool) {
+	var result []string
+	for _, tag := range tags {
+		if tag != "" && !slices.Contains(remove, tag) {
+			result = append(result, tag)
+		}
+	}
+	changed := len(result) != len(tags)
+	for _, tag := range add {
+		if !slices.Contains(result, tag) {
+			result = append(result, tag)
+			changed = true
+		}
+	}
+	return result, changed
+}
 
-	switch {
-	case due == "":
-		err := validateTags(tagList, h.AllTags)
-		if err != nil {
+// tag modes of tasks queries
+const (
+	// tagModeAny tasks with any of tags
+	tagModeAny = ""
+	// tagModeFull tasks with all tags
+	tagModeFull = "full"
+	// tagModeShort tasks with all tags and without other tags
+	tagModeShort = "short"
+)
+
+// tasksByTag returns tasks with tags from 'tag' query param in the tag mode and due date from 'due' query param,
+// tasks are filtered by taskFilter
+func (h *Handlers) tasksByTag(db storage.Storage, r *http.Request, mode string) (*storage.Tasks, error) {
+	user := request.UserFromContext(r.Context())
+	tagList, err := queryTags(r)
+	if err != nil {
+		return nil, err
+	}
+	due := r.URL.Query().Get("due")
+
+	filter, err := taskFilter(r)
+	if err != nil {
+		return nil, err
+	}
+	allTags := tagsList.NewTagsMemoryList(&db, user.Id, filter.ProjectId, h.Log)
+
+	if due == "" {
+		if err = validateTags(tagList, allTags); err != nil {
 			return nil, err
 		}
-		return h.Db.GetTasksByTagShort(tagList)
-	default:
-		err := validateTagsAndDue(tagList, due, h.AllTags)
-		deuDate, _ := time.Parse(time.RFC3339, due)
-		if err != nil {
-			return nil, err
+		switch mode {
+		case tagModeFull:
+			return db.GetTasksByTagFull(user.Id, tagList, filter)
+		case tagModeShort:
+			return db.GetTasksByTagShort(user.Id, tagList, filter)
+		default:
+			return db.GetTasksByTag(user.Id, tagList, filter)
 		}
-		return h.Db.GetTasksByDueAndTagShort(tagList, &deuDate)
 	}
+
+	if err = validateTagsAndDue(tagList, due, allTags); err != nil {
+		return nil, err
+	}
+	dueDate, _ := time.Parse(time.RFC3339, due)
+	switch mode {
+	case tagModeFull:
+		return db.GetTasksByDueAndTagFull(user.Id, tagList, &dueDate, filter)
+	case tagModeShort:
+		return db.GetTasksByDueAndTagShort(user.Id, tagList, &dueDate, filter)
+	default:
+		return db.GetTasksByTagAndDue(user.Id, tagList, &dueDate, filter)
+	}
+}
+
+// queryTags returns tags from 'tag' query param separated by ',', error if param is empty or has empty tag
+func queryTags(r *http.Request) ([]string, error) {
+	tag := r.URL.Query().Get("tag")
+	if tag == "" {
+		return nil, fmt.Errorf("expect tag query param")
+	}
+	tagList := strings.Split(tag, ",")
+	if slices.Contains(tagList, "") {
+		return nil, fmt.Errorf("tags must not be empty, given: '%s'", tag)
+	}
+	return tagList, nil
 }
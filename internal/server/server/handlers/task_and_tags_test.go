@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+func TestBulkTagHandler(t *testing.T) {
+	before := map[string][]string{"work": {"work"}, "both": {"work", "home"}, "home": {"home"}}
+
+	tests := []struct {
+		name      string
+		dryRun    bool
+		wantCount int
+		wantTasks int
+		wantTags  map[string][]string
+		wantUndo  bool
+	}{
+		{
+			name:      "dry run changes nothing",
+			dryRun:    true,
+			wantCount: 2,
+			wantTasks: 2,
+			wantTags:  before,
+			wantUndo:  false,
+		},
+		{
+			name:      "change of matching tasks",
+			dryRun:    false,
+			wantCount: 2,
+			wantTasks: 0,
+			wantTags:  map[string][]string{"work": {"work", "urgent"}, "both": {"work", "urgent"}, "home": {"home"}},
+			wantUndo:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, user := newTestHandlers(t)
+			for _, tag := range []string{"home", "urgent"} {
+				if err := h.Db.CreateTag(user.Id, 0, tag); err != nil {
+					t.Fatal(err)
+				}
+			}
+			due := time.Now().Add(24 * time.Hour)
+			for text, tags := range before {
+				if _, err := h.Db.CreateTask(user.Id, &storage.TaskCreate{Text: text, Tags: tags, Due: &due}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			history, err := h.Db.GetAuditLog(&storage.AuditFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := fmt.Sprintf(`{"add": ["urgent"], "remove": ["home"], "dry_run": %t}`, tt.dryRun)
+			w := serveTest(h.BulkTagHandler, user, http.MethodPost, "/task/tag/bulk?tag=work", body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body = %s", w.Code, w.Body)
+			}
+
+			var resp struct {
+				Data response.BulkTagResult `json:"data"`
+			}
+			if err = json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Data.Count != tt.wantCount || len(resp.Data.Tasks) != tt.wantTasks {
+				t.Errorf("count = %d, tasks = %d, want %d and %d",
+					resp.Data.Count, len(resp.Data.Tasks), tt.wantCount, tt.wantTasks)
+			}
+
+			tasks, err := h.Db.GetAllTasks(user.Id, &storage.TaskFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tags := make(map[string][]string)
+			for _, task := range tasks.Tasks {
+				tags[task.Text] = task.Tags
+			}
+			if !reflect.DeepEqual(tags, tt.wantTags) {
+				t.Errorf("tags = %v, want %v", tags, tt.wantTags)
+			}
+
+			after, err := h.Db.GetAuditLog(&storage.AuditFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed := len(after.Entries) != len(history.Entries); changed != !tt.dryRun {
+				t.Errorf("audit log is changed = %v, want %v", changed, !tt.dryRun)
+			}
+			if undo := w.Header().Get(UndoTokenHeader) != ""; undo != tt.wantUndo {
+				t.Errorf("undo token is sent = %v, want %v", undo, tt.wantUndo)
+			}
+		})
+	}
+}
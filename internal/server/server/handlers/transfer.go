@@ -0,0 +1,368 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"web/internal/format"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+	tagsList "web/storage/tags-list"
+)
+
+// file formats of tasks export and import
+const (
+	formatCSV      = "csv"
+	formatICS      = "ics"
+	formatTodoTxt  = "todotxt"
+	formatMarkdown = "markdown"
+)
+
+// maxImportSize max size of imported file, 1 MB
+const maxImportSize = 1 << 20
+
+// ExportTasksHandler returns tasks matching filter as file
+// @Summary Export tasks
+// @Description Download tasks matching filter as file. Filter is the same as in GET /task/, with tag param tasks are filtered as in GET /task/tag/ and GET /task/tag/{mode}/.
+// @Description Format csv: header and task per line, columns: id, text, tags (separated by ';'), due, priority, project_id, assignee, parent_id, done
+// @Description Format ics: iCalendar with VTODO of each task, or VEVENT with component=event, tags are categories
+// @Description Format todotxt: todo.txt line of each task, tags are +project, due is due:2006-01-02, priority P0-P4 is (A)-(E)
+// @Description Format markdown: checklist '- [ ] text #tag (due 2006-01-02)' with heading of each group, tasks are grouped by first tag or by due date with group=due
+// @Tags transfer
+// @Produce text/csv
+// @Produce text/calendar
+// @Produce plain
+// @Produce text/markdown
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param format query string false "File format: csv (default), ics, todotxt or markdown"
+// @Param component query string false "Component of ics: todo (default) or event"
+// @Param group query string false "Groups of markdown: tag (default) or due"
+// @Param tag query string false "Tags separated by ','"
+// @Param mode query string false "Tag mode: full or short"
+// @Param due query string false "Due, used with tag"
+// @Param project query int false "Project id"
+// @Param assignee query string false "Assignee name"
+// @Param priority query string false "Priorities separated by ',': P0-P4"
+// @Param sort query string false "Order of tasks: priority (then due), due or position"
+// @Param ready query bool false "Only not done tasks without not done blockers"
+// @Param due_from query string false "Only tasks due at or after this time, format: 2006-01-02T15:04:05Z"
+// @Param due_to query string false "Only tasks due before this time, format: 2006-01-02T15:04:05Z"
+// @Success 200 {file} file
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /task/export [get]
+func (h *Handlers) ExportTasksHandler(w http.ResponseWriter, r *http.Request) {
+	fileFormat, err := formatParam(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	component, err := icsComponent(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	group, err := groupParam(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	tasks, err := h.exportTasks(r)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	// file is written to buffer first, so failed export returns error response
+	var file bytes.Buffer
+	var contentType, fileName string
+	switch fileFormat {
+	case formatICS:
+		contentType, fileName = "text/calendar; charset=utf-8", "tasks.ics"
+		err = format.WriteICS(&file, tasks, component)
+	case formatTodoTxt:
+		contentType, fileName = "text/plain; charset=utf-8", "todo.txt"
+		err = format.WriteTodoTxt(&file, tasks)
+	case formatMarkdown:
+		contentType, fileName = "text/markdown; charset=utf-8", "tasks.md"
+		err = format.WriteMarkdown(&file, tasks, group)
+	default:
+		contentType, fileName = "text/csv; charset=utf-8", "tasks.csv"
+		err = format.WriteCSV(&file, tasks)
+	}
+	if err != nil {
+		h.Log.Error(fmt.Sprintf("handlers.ExportTasksHandler: %v", err))
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("can't export tasks")))
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	if _, err = file.WriteTo(w); err != nil {
+		h.Log.Error(fmt.Sprintf("handlers.ExportTasksHandler: %v", err))
+	}
+}
+
+// exportTasks returns tasks matching filter of the request, tasks by tag if 'tag' query param is set
+func (h *Handlers) exportTasks(r *http.Request) ([]storage.Task, error) {
+	var tasks *storage.Tasks
+	var err error
+	if r.URL.Query().Get("tag") != "" {
+		mode := r.URL.Query().Get("mode")
+		switch mode {
+		case tagModeAny, tagModeFull, tagModeShort:
+		default:
+			return nil, fmt.Errorf("expect mode == full or short, got %v", mode)
+		}
+		tasks, err = h.tasksByTag(h.Db, r, mode)
+	} else {
+		var filter *storage.TaskFilter
+		if filter, err = taskFilter(r); err != nil {
+			return nil, err
+		}
+		user := request.UserFromContext(r.Context())
+		tasks, err = h.Db.GetAllTasks(user.Id, filter)
+	}
+
+	if errSql, ok := err.(storage.SqlError); ok && errSql.GetCode() == http.StatusNotFound {
+		// no matching tasks, file has only header
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tasks.Tasks, nil
+}
+
+// ImportTasksHandler creates tasks from file
+// @Summary Import tasks
+// @Description Create tasks from file in one operation for POST /undo, body is the file. Each record is validated as task in POST /task/, but due can be in the past, invalid records are skipped.
+// @Description Format csv: header and task per line, columns are found by header: text, tags (separated by ';'), due, priority, project_id, assignee, parent_id, done. Text, tags and due are required, other columns are skipped.
+// @Description Format ics: VTODO of iCalendar, SUMMARY is text, CATEGORIES are tags, DUE is due, PRIORITY 1-9 is P0-P4, STATUS:COMPLETED - done. Other components are skipped.
+// @Description Format todotxt: todo.txt lines, +project and @context are tags, due:2006-01-02 is due, (A)-(E) is P0-P4, later letters are P4, x - done. Empty lines are skipped.
+// @Description Format markdown: checklist items '- [ ] text #tag (due 2006-01-02)', #tag is tag, '- [x]' - done. Other lines are skipped.
+// @Description Map renames columns of csv file: 'Title:text,Labels:tags', categories of ics file, +project and @context of todotxt file or #tag of markdown file to tags: 'Business:work'. With create_tags unknown tags are created in project of the task.
+// @Description Returns result of each record
+// @Tags transfer
+// @Accept text/csv
+// @Accept text/calendar
+// @Accept plain
+// @Accept text/markdown
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param format query string false "File format: csv (default), ics, todotxt or markdown"
+// @Param map query string false "Column of csv file to column of tasks, category of ics file, project of todotxt file or tag of markdown file to tag, pairs 'from:to' separated by ','"
+// @Param create_tags query bool false "Create unknown tags"
+// @Param file body string true "File"
+// @Success 200 {object} response.OkResponse{data=response.ImportResult}
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 413 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /task/import [post]
+func (h *Handlers) ImportTasksHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	fileFormat, err := formatParam(r)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	createTags := false
+	if text := query.Get("create_tags"); text != "" {
+		if createTags, err = strconv.ParseBool(text); err != nil {
+			h.JSON(w, response.Error(http.StatusBadRequest, fmt.Errorf("expect create_tags as true or false, given: '%s'", text)))
+			return
+		}
+	}
+	mapping := make(map[string]string)
+	if text := query.Get("map"); text != "" {
+		for _, pair := range strings.Split(text, ",") {
+			from, to, ok := strings.Cut(pair, ":")
+			if !ok {
+				h.JSON(w, response.Error(http.StatusBadRequest, fmt.Errorf("expect map pair as 'from:to', given: '%s'", pair)))
+				return
+			}
+			mapping[from] = to
+		}
+	}
+
+	var rows []format.Row
+	file := http.MaxBytesReader(w, r.Body, maxImportSize)
+	switch fileFormat {
+	case formatICS:
+		rows, err = format.ReadICS(file, mapping)
+	case formatTodoTxt:
+		rows, err = format.ReadTodoTxt(file, mapping)
+	case formatMarkdown:
+		rows, err = format.ReadMarkdown(file, mapping)
+	default:
+		rows, err = format.ReadCSV(file, mapping)
+	}
+	if err != nil {
+		var errSize *http.MaxBytesError
+		if errors.As(err, &errSize) {
+			h.JSON(w, response.Error(http.StatusRequestEntityTooLarge, fmt.Errorf("file is larger than %d bytes", maxImportSize)))
+			return
+		}
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+
+	result := response.ImportResult{Rows: make([]response.ImportRow, len(rows))}
+	err = db.Transaction(func(tx storage.Storage) error {
+		// tags of each project are loaded once
+		projectTags := make(map[int]*tagsList.TagsList)
+		for i, row := range rows {
+			result.Rows[i].Line = row.Line
+
+			task, err := row.Task, row.Err
+			var created *storage.Task
+			if err == nil {
+				created, err = h.importTask(tx, user.Id, &task, createTags, projectTags)
+			}
+			if err != nil {
+				status := http.StatusBadRequest
+				if errSql, ok := err.(storage.SqlError); ok {
+					status = errSql.GetCode()
+				}
+				result.Rows[i].Status, result.Rows[i].Error = status, err.Error()
+				result.Failed++
+				continue
+			}
+			result.Rows[i].Status, result.Rows[i].Task = http.StatusOK, created
+			result.Created++
+		}
+		return nil
+	})
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+
+	if result.Created > 0 {
+		w.Header().Set(UndoTokenHeader, token)
+	}
+	h.JSON(w, response.OK(result))
+}
+
+// importTask validates and creates imported task, projectTags - loaded tags of projects.
+// Unknown tags are created after the task with createTags
+func (h *Handlers) importTask(tx storage.Storage, userId int, task *storage.Task, createTags bool,
+	projectTags map[int]*tagsList.TagsList) (*storage.Task, error) {
+	requestData := request.TaskRequest{
+		Text:      task.Text,
+		Tags:      task.Tags,
+		Due:       task.Due,
+		ProjectId: task.ProjectId,
+		Assignee:  task.Assignee,
+		Priority:  task.Priority,
+		ParentId:  task.ParentId,
+	}
+
+	allTags, ok := projectTags[task.ProjectId]
+	if !ok {
+		allTags = tagsList.NewTagsMemoryList(&tx, userId, task.ProjectId, h.Log)
+		projectTags[task.ProjectId] = allTags
+	}
+	validTags := allTags
+	var newTags []string
+	if createTags {
+		validTags = &tagsList.TagsList{}
+		for tag := range *allTags {
+			(*validTags)[tag] = true
+		}
+		for _, tag := range task.Tags {
+			if (*allTags)[tag] {
+				continue
+			}
+			if err := validateTagName(tag); err != nil {
+				return nil, err
+			}
+			(*validTags)[tag] = true
+			newTags = append(newTags, tag)
+		}
+	}
+
+	// due of imported task can be in the past, exported overdue tasks are imported back
+	if err := requestData.ValidateImport(validTags); err != nil {
+		return nil, err
+	}
+
+	created, err := tx.CreateTask(userId, taskCreate(&requestData))
+	if err != nil {
+		return nil, err
+	}
+	for _, tag := range newTags {
+		if err = tx.CreateTag(userId, task.ProjectId, tag); err != nil {
+			return nil, err
+		}
+		(*allTags)[tag] = true
+	}
+	if task.Done {
+		return tx.UpdateTask(userId, created.Id, &storage.TaskUpdate{Done: &task.Done})
+	}
+	return created, nil
+}
+
+// formatParam returns file format from 'format' query param, csv if param is not set
+func formatParam(r *http.Request) (string, error) {
+	switch fileFormat := r.URL.Query().Get("format"); fileFormat {
+	case "", formatCSV:
+		return formatCSV, nil
+	case formatICS, formatTodoTxt, formatMarkdown:
+		return fileFormat, nil
+	default:
+		return "", fmt.Errorf("unknown format '%s', expect one of: %s, %s, %s, %s",
+			fileFormat, formatCSV, formatICS, formatTodoTxt, formatMarkdown)
+	}
+}
+
+// groupParam returns groups of exported markdown from 'group' query param: tag (default) or due
+func groupParam(r *http.Request) (string, error) {
+	switch group := r.URL.Query().Get("group"); group {
+	case "", format.GroupTag:
+		return format.GroupTag, nil
+	case format.GroupDue:
+		return group, nil
+	default:
+		return "", fmt.Errorf("unknown group '%s', expect %s or %s", group, format.GroupTag, format.GroupDue)
+	}
+}
+
+// icsComponent returns iCalendar component of exported tasks from 'component' query param: todo (default) or event
+func icsComponent(r *http.Request) (string, error) {
+	switch component := r.URL.Query().Get("component"); component {
+	case "", "todo":
+		return format.ICSTodo, nil
+	case "event":
+		return format.ICSEvent, nil
+	default:
+		return "", fmt.Errorf("unknown component '%s', expect todo or event", component)
+	}
+}
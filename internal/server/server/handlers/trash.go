@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"github.com/go-chi/chi"
+	"net/http"
+	"strconv"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+// GetTrashHandler returns trashed tasks and tags
+// @Summary Get trash
+// @Description Get trashed tasks the user can restore and trashed tags of own projects and projects with write share
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} response.OkResponse{data=storage.Trash}
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /trash [get]
+func (h *Handlers) GetTrashHandler(w http.ResponseWriter, r *http.Request) {
+	user := request.UserFromContext(r.Context())
+
+	trash, err := h.Db.GetTrash(user.Id)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+
+	h.JSON(w, response.OK(trash))
+}
+
+// RestoreTaskHandler restores task from trash
+// @Summary Restore task
+// @Description Restore task from trash with its checklist, comments and attachments.
+// @Description Task becomes top level task if its parent is not restored
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Task id"
+// @Success 200 {object} response.OkResponse{data=storage.Task}
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /trash/{id}/restore [post]
+func (h *Handlers) RestoreTaskHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+	task, err := db.RestoreTask(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	w.Header().Set(UndoTokenHeader, token)
+	h.JSON(w, response.OK(task))
+}
+
+// RestoreTagHandler restores tag from trash
+// @Summary Restore tag
+// @Description Restore tag from trash
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Tag id"
+// @Success 200 {object} response.OkResponse{data=storage.Tag}
+// @Header 200 {string} X-Undo-Token "Token of the operation for POST /undo"
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /trash/tag/{id}/restore [post]
+func (h *Handlers) RestoreTagHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	db, token, err := h.undoable()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+	tag, err := db.RestoreTag(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	w.Header().Set(UndoTokenHeader, token)
+	h.JSON(w, response.OK(tag))
+}
+
+// EmptyTrashHandler deletes trashed tasks and tags forever
+// @Summary Empty trash
+// @Description Delete tasks and tags of the trash forever, tasks are deleted with checklists, comments and attachments
+// @Tags trash
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /trash [delete]
+func (h *Handlers) EmptyTrashHandler(w http.ResponseWriter, r *http.Request) {
+	user := request.UserFromContext(r.Context())
+
+	err := h.Db.EmptyTrash(user.Id)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		return
+	}
+
+	h.DeleteUnusedBlobs()
+
+	h.JSON(w, response.OK())
+}
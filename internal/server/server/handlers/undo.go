@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+	"web/internal/auth"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/storage"
+)
+
+// UndoTokenHeader response header with token of undoable operation made by the request
+const UndoTokenHeader = "X-Undo-Token"
+
+// defaultUndoWindow used if undo.window is not set in config
+const defaultUndoWindow = 15 * time.Minute
+
+// undoable returns storage which records changes of the request as one undoable operation, and token of the operation.
+// Token is sent in UndoTokenHeader of successful response
+func (h *Handlers) undoable() (storage.Storage, string, error) {
+	token, err := auth.NewToken()
+	if err != nil {
+		return nil, "", err
+	}
+	return h.Db.Operation(token), token, nil
+}
+
+// UndoHandler reverses the operation
+// @Summary Undo operation
+// @Description Reverse changes of the operation by token from X-Undo-Token header of its response, or of the newest not undone operation of the user without token.
+// @Description Creates, updates, moves and deletes of tasks and tags, restores from trash can be undone by their author within undo window, if changed tasks and tags were not changed after the operation.
+// @Description Returns changes of the undone operation
+// @Tags undo
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param token query string false "Undo token of the operation"
+// @Success 200 {object} response.OkResponse{data=storage.AuditLog}
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /undo [post]
+func (h *Handlers) UndoHandler(w http.ResponseWriter, r *http.Request) {
+	user := request.UserFromContext(r.Context())
+
+	window := h.Cfg.Undo.Window
+	if window <= 0 {
+		window = defaultUndoWindow
+	}
+
+	undone, err := h.Db.Undo(user.Id, r.URL.Query().Get("token"), window)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusInternalServerError, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(undone))
+}
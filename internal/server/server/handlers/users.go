@@ -0,0 +1,325 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/go-chi/chi"
+	"net/http"
+	"strconv"
+	"time"
+	"web/internal/auth"
+	"web/internal/server/context/request"
+	"web/internal/server/context/response"
+	"web/internal/server/middleware"
+	"web/internal/storage"
+)
+
+// defaultSessionTTL used if auth.sessionTTL is not set in config
+const defaultSessionTTL = 24 * time.Hour
+
+// RegisterHandler creates new user
+// @Summary Register new user
+// @Description Register new user with uniq name. Name: 3-32 latin letters, digits, '_', '-', '.'. Password: 8-72 characters
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body request.UserRequest true "User"
+// @Success 200 {object} response.OkResponse{data=storage.User}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /user/register [post]
+func (h *Handlers) RegisterHandler(w http.ResponseWriter, r *http.Request) {
+	var requestData request.UserRequest
+
+	err := h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	passwordHash, err := auth.HashPassword(requestData.Password)
+	if err != nil {
+		h.Log.Error(err.Error())
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("internal server error")))
+		return
+	}
+
+	user, err := h.Db.CreateUser(requestData.Name, passwordHash)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(user))
+}
+
+// LoginHandler checks user credentials and issues session token
+// @Summary Login
+// @Description Login with name and password, returns session token. Use it in header: 'Authorization: Bearer <token>'
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param user body request.UserRequest true "User"
+// @Success 200 {object} response.OkResponse{data=storage.Session}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /user/login [post]
+func (h *Handlers) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	var requestData request.UserRequest
+
+	err := h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user, passwordHash, err := h.Db.GetUserCredentials(requestData.Name)
+	// INFO: same error for unknown user and wrong password, don't tell which users exist
+	if err != nil || !auth.CheckPassword(passwordHash, requestData.Password) {
+		h.JSON(w, response.Error(http.StatusUnauthorized, fmt.Errorf("invalid name or password")))
+		return
+	}
+
+	token, err := auth.NewToken()
+	if err != nil {
+		h.Log.Error(err.Error())
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("internal server error")))
+		return
+	}
+
+	ttl := h.Cfg.Auth.SessionTTL
+	if ttl <= 0 {
+		ttl = defaultSessionTTL
+	}
+	expiresAt := time.Now().Add(ttl).UTC()
+
+	err = h.Db.CreateSession(user.Id, auth.HashToken(token), expiresAt)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("internal server error")))
+		return
+	}
+
+	h.JSON(w, response.OK(storage.Session{Token: token, ExpiresAt: expiresAt.Format(time.RFC3339)}))
+}
+
+// LogoutHandler deletes current session
+// @Summary Logout
+// @Description Invalidate session token from the Authorization header
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /user/logout [post]
+func (h *Handlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	// user authenticated by api key has no session
+	token, ok := middleware.BearerToken(r)
+	if !ok {
+		h.JSON(w, response.Error(http.StatusBadRequest, fmt.Errorf("expect session token in Authorization header")))
+		return
+	}
+
+	err := h.Db.DeleteSession(auth.HashToken(token))
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK())
+}
+
+// GetMeHandler returns current user
+// @Summary Get current user
+// @Description Get user authenticated by the Authorization header
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} response.OkResponse{data=storage.User}
+// @Failure 401 {object} response.ErrorResponse
+// @Router /user/me [get]
+func (h *Handlers) GetMeHandler(w http.ResponseWriter, r *http.Request) {
+	h.JSON(w, response.OK(request.UserFromContext(r.Context())))
+}
+
+// CreateApiKeyHandler creates new api key of the current user
+// @Summary Create api key
+// @Description Create api key with role not higher than role of the user, use it in header: 'X-API-Key: <key>'. Key is returned only once
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param key body request.ApiKeyRequest true "Api key"
+// @Success 200 {object} response.OkResponse{data=storage.ApiKey}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /user/apikey [post]
+func (h *Handlers) CreateApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var requestData request.ApiKeyRequest
+
+	err := h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user := request.UserFromContext(r.Context())
+	role := requestData.Role
+	if role == "" {
+		role = user.Role
+	}
+	if storage.WeakerRole(role, user.Role) != role {
+		h.JSON(w, response.Error(http.StatusForbidden, fmt.Errorf("role '%s' exceeds your role '%s'", role, user.Role)))
+		return
+	}
+
+	key, err := auth.NewToken()
+	if err != nil {
+		h.Log.Error(err.Error())
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("internal server error")))
+		return
+	}
+
+	apiKey, err := h.Db.CreateApiKey(user.Id, requestData.Name, role, auth.HashToken(key))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("internal server error")))
+		return
+	}
+	apiKey.Key = key
+
+	h.JSON(w, response.OK(apiKey))
+}
+
+// GetApiKeysHandler returns api keys of the current user
+// @Summary Get api keys
+// @Description Get api keys of the current user, keys itself are not returned
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Success 200 {object} response.OkResponse{data=storage.ApiKeys}
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 500 {object} response.ErrorResponse
+// @Router /user/apikey [get]
+func (h *Handlers) GetApiKeysHandler(w http.ResponseWriter, r *http.Request) {
+	user := request.UserFromContext(r.Context())
+
+	keys, err := h.Db.GetApiKeys(user.Id)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusInternalServerError, fmt.Errorf("internal server error")))
+		return
+	}
+
+	h.JSON(w, response.OK(keys))
+}
+
+// DeleteApiKeyHandler deletes api key of the current user
+// @Summary Delete api key
+// @Description Delete api key by id
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param id path int true "Api key id"
+// @Success 200 {object} response.OkResponseEmpty
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Router /user/apikey/{id} [delete]
+func (h *Handlers) DeleteApiKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+	user := request.UserFromContext(r.Context())
+
+	err = h.Db.DeleteApiKey(user.Id, id)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK())
+}
+
+// SetUserRoleHandler changes role of the user
+// @Summary Set user role
+// @Description Set role of the user: admin, member or read-only. Admin only, the only admin can't lose admin role
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Param name path string true "User name"
+// @Param role body request.RoleRequest true "Role"
+// @Success 200 {object} response.OkResponse{data=storage.User}
+// @Failure 400 {object} response.ErrorResponse
+// @Failure 401 {object} response.ErrorResponse
+// @Failure 403 {object} response.ErrorResponse
+// @Failure 404 {object} response.ErrorResponse
+// @Failure 409 {object} response.ErrorResponse
+// @Router /user/{name}/role [put]
+func (h *Handlers) SetUserRoleHandler(w http.ResponseWriter, r *http.Request) {
+	var requestData request.RoleRequest
+
+	err := h.DecodeJSON(r.Body, &requestData)
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	err = requestData.ValidateRequest()
+	if err != nil {
+		h.JSON(w, response.Error(http.StatusBadRequest, err))
+		return
+	}
+
+	user, err := h.Db.SetUserRole(chi.URLParam(r, "name"), requestData.Role)
+	if err != nil {
+		switch errSql := err.(type) {
+		case storage.SqlError:
+			h.JSON(w, response.Error(errSql.GetCode(), errSql))
+		default:
+			h.JSON(w, response.Error(http.StatusBadRequest, err))
+		}
+		return
+	}
+
+	h.JSON(w, response.OK(user))
+}
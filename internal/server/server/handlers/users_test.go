@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"web/internal/auth"
+	"web/internal/server/context/request"
+	"web/internal/storage"
+)
+
+func TestLoginHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{name: "valid credentials", body: `{"name": "bob", "password": "password123"}`, wantStatus: http.StatusOK},
+		{name: "wrong password", body: `{"name": "bob", "password": "password321"}`, wantStatus: http.StatusUnauthorized},
+		{name: "unknown user", body: `{"name": "nobody", "password": "password123"}`, wantStatus: http.StatusUnauthorized},
+		{name: "empty body", body: ``, wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, _ := newTestHandlers(t)
+			hash, err := auth.HashPassword("password123")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = h.Db.CreateUser("bob", hash); err != nil {
+				t.Fatal(err)
+			}
+
+			w := serveTest(h.LoginHandler, nil, http.MethodPost, "/user/login", tt.body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data storage.Session `json:"data"`
+			}
+			if err = json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			// only hash of the token is stored, so session is found by hash
+			user, err := h.Db.GetUserBySession(auth.HashToken(resp.Data.Token))
+			if err != nil {
+				t.Fatalf("GetUserBySession() error = %v", err)
+			}
+			if user.Name != "bob" {
+				t.Errorf("user = %q, want %q", user.Name, "bob")
+			}
+		})
+	}
+}
+
+func TestLogoutHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "session token", authorization: "Bearer token", wantStatus: http.StatusOK},
+		{name: "unknown session token", authorization: "Bearer unknown", wantStatus: http.StatusNotFound},
+		{name: "no session token", authorization: "", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, user := newTestHandlers(t)
+			hash := auth.HashToken("token")
+			if err := h.Db.CreateSession(user.Id, hash, time.Now().Add(time.Hour)); err != nil {
+				t.Fatal(err)
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/user/logout", strings.NewReader(""))
+			r = r.WithContext(request.WithUser(r.Context(), user))
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			h.LogoutHandler(w, r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body)
+			}
+
+			_, err := h.Db.GetUserBySession(hash)
+			if loggedOut := err != nil; loggedOut != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("session is deleted = %v, want %v", loggedOut, tt.wantStatus == http.StatusOK)
+			}
+		})
+	}
+}
+
+func TestCreateApiKeyHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		userRole   string
+		keyRole    string
+		wantStatus int
+		wantRole   string
+	}{
+		{name: "role of the user by default", userRole: storage.RoleMember, keyRole: "", wantStatus: http.StatusOK, wantRole: storage.RoleMember},
+		{name: "weaker role", userRole: storage.RoleMember, keyRole: storage.RoleReadOnly, wantStatus: http.StatusOK, wantRole: storage.RoleReadOnly},
+		{name: "same role", userRole: storage.RoleAdmin, keyRole: storage.RoleAdmin, wantStatus: http.StatusOK, wantRole: storage.RoleAdmin},
+		{name: "role higher than role of the user", userRole: storage.RoleMember, keyRole: storage.RoleAdmin, wantStatus: http.StatusForbidden},
+		{name: "role higher than read-only", userRole: storage.RoleReadOnly, keyRole: storage.RoleMember, wantStatus: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, user := newTestHandlers(t)
+			user.Role = tt.userRole
+
+			body := fmt.Sprintf(`{"name": "key", "role": %q}`, tt.keyRole)
+			w := serveTest(h.CreateApiKeyHandler, user, http.MethodPost, "/user/apikey", body)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data storage.ApiKey `json:"data"`
+			}
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			keyUser, err := h.Db.GetUserByApiKey(auth.HashToken(resp.Data.Key))
+			if err != nil {
+				t.Fatalf("GetUserByApiKey() error = %v", err)
+			}
+			if keyUser.Role != tt.wantRole {
+				t.Errorf("role of the key = %q, want %q", keyUser.Role, tt.wantRole)
+			}
+		})
+	}
+}
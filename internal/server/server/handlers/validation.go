@@ -25,7 +25,7 @@ func validateDue(dueDate string) error {
 
 func validateTags(tags []string, allTags *tagsList.TagsList) error {
 	for _, tag := range tags {
-		if tag[0] == ' ' {
+		if tag == "" || tag[0] == ' ' {
 			return fmt.Errorf("tags must not be empty")
 		}
 		if _, err := strconv.Atoi(tag); err == nil {
@@ -25,6 +25,72 @@ type HandlerMethods interface {
 	GetTasksByDueDateHandler(w http.ResponseWriter, r *http.Request)
 	// CreateTaskHandler create new task with specified params
 	CreateTaskHandler(w http.ResponseWriter, req *http.Request)
+	// UpdateTaskHandler change fields of the task
+	UpdateTaskHandler(w http.ResponseWriter, r *http.Request)
+	// MoveTaskHandler move task in user defined order
+	MoveTaskHandler(w http.ResponseWriter, r *http.Request)
+	// GetAssignedTasksHandler get tasks assigned to current user
+	GetAssignedTasksHandler(w http.ResponseWriter, r *http.Request)
+	// GetTaskTreeHandler get task with subtasks and checklists
+	GetTaskTreeHandler(w http.ResponseWriter, r *http.Request)
+	// GetTaskProgressHandler get done part of the task
+	GetTaskProgressHandler(w http.ResponseWriter, r *http.Request)
+	// AddDependencyHandler add blocker of the task
+	AddDependencyHandler(w http.ResponseWriter, r *http.Request)
+	// RemoveDependencyHandler remove blocker of the task
+	RemoveDependencyHandler(w http.ResponseWriter, r *http.Request)
+	// GetDependencyGraphHandler get tasks with dependencies
+	GetDependencyGraphHandler(w http.ResponseWriter, r *http.Request)
+	// GetCommentsHandler get comments of the task
+	GetCommentsHandler(w http.ResponseWriter, r *http.Request)
+	// CreateCommentHandler add comment to the task
+	CreateCommentHandler(w http.ResponseWriter, r *http.Request)
+	// DeleteCommentHandler delete comment of the task
+	DeleteCommentHandler(w http.ResponseWriter, r *http.Request)
+	// GetAttachmentsHandler get attachments of the task
+	GetAttachmentsHandler(w http.ResponseWriter, r *http.Request)
+	// CreateAttachmentHandler upload file to the task
+	CreateAttachmentHandler(w http.ResponseWriter, r *http.Request)
+	// DownloadAttachmentHandler download file of the attachment
+	DownloadAttachmentHandler(w http.ResponseWriter, r *http.Request)
+	// DeleteAttachmentHandler delete attachment of the task
+	DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request)
+	// GetTaskHistoryHandler get changes of the task
+	GetTaskHistoryHandler(w http.ResponseWriter, r *http.Request)
+	// GetAuditLogHandler get changes of all users
+	GetAuditLogHandler(w http.ResponseWriter, r *http.Request)
+	// GetTrashHandler get trashed tasks and tags
+	GetTrashHandler(w http.ResponseWriter, r *http.Request)
+	// RestoreTaskHandler restore task from trash
+	RestoreTaskHandler(w http.ResponseWriter, r *http.Request)
+	// RestoreTagHandler restore tag from trash
+	RestoreTagHandler(w http.ResponseWriter, r *http.Request)
+	// EmptyTrashHandler delete trashed tasks and tags forever
+	EmptyTrashHandler(w http.ResponseWriter, r *http.Request)
+	// BatchTasksHandler apply operations with tasks in one transaction
+	BatchTasksHandler(w http.ResponseWriter, r *http.Request)
+	// BulkTagHandler add and remove tags of tasks matching filter
+	BulkTagHandler(w http.ResponseWriter, r *http.Request)
+	// ExportTasksHandler return tasks matching filter as file
+	ExportTasksHandler(w http.ResponseWriter, r *http.Request)
+	// ImportTasksHandler create tasks from file
+	ImportTasksHandler(w http.ResponseWriter, r *http.Request)
+	// BackupHandler return snapshot of the database
+	BackupHandler(w http.ResponseWriter, r *http.Request)
+	// RestoreHandler replace all data with snapshot of the database
+	RestoreHandler(w http.ResponseWriter, r *http.Request)
+	// CalendarHandler return iCalendar feed of tasks of the calendar token owner
+	CalendarHandler(w http.ResponseWriter, r *http.Request)
+	// UndoHandler reverse the newest operation of the user or operation by token
+	UndoHandler(w http.ResponseWriter, r *http.Request)
+	// GetChecklistHandler get checklist of the task
+	GetChecklistHandler(w http.ResponseWriter, r *http.Request)
+	// CreateChecklistItemHandler add item to checklist of the task
+	CreateChecklistItemHandler(w http.ResponseWriter, r *http.Request)
+	// UpdateChecklistItemHandler change checklist item
+	UpdateChecklistItemHandler(w http.ResponseWriter, r *http.Request)
+	// DeleteChecklistItemHandler delete checklist item
+	DeleteChecklistItemHandler(w http.ResponseWriter, r *http.Request)
 	// DeleteTasksHandler delete all tasks
 	DeleteTasksHandler(w http.ResponseWriter, req *http.Request)
 	// DeleteTaskHandler delete task by id
@@ -35,4 +101,46 @@ type HandlerMethods interface {
 	DeleteTagsHandler(w http.ResponseWriter, r *http.Request)
 	// DeleteTagHandler delete tag by name
 	DeleteTagHandler(w http.ResponseWriter, r *http.Request)
+	// GetProjectsHandler get all projects
+	GetProjectsHandler(w http.ResponseWriter, r *http.Request)
+	// GetProjectHandler get project by id
+	GetProjectHandler(w http.ResponseWriter, r *http.Request)
+	// CreateProjectHandler create new project
+	CreateProjectHandler(w http.ResponseWriter, r *http.Request)
+	// UpdateProjectHandler rename project
+	UpdateProjectHandler(w http.ResponseWriter, r *http.Request)
+	// DeleteProjectHandler delete project with its tasks and tags
+	DeleteProjectHandler(w http.ResponseWriter, r *http.Request)
+	// ShareTaskHandler share task with the user
+	ShareTaskHandler(w http.ResponseWriter, r *http.Request)
+	// UnshareTaskHandler revoke share of the task
+	UnshareTaskHandler(w http.ResponseWriter, r *http.Request)
+	// GetTaskSharesHandler get shares of the task
+	GetTaskSharesHandler(w http.ResponseWriter, r *http.Request)
+	// ShareProjectHandler share project with the user
+	ShareProjectHandler(w http.ResponseWriter, r *http.Request)
+	// UnshareProjectHandler revoke share of the project
+	UnshareProjectHandler(w http.ResponseWriter, r *http.Request)
+	// GetProjectSharesHandler get shares of the project
+	GetProjectSharesHandler(w http.ResponseWriter, r *http.Request)
+	// RegisterHandler create new user
+	RegisterHandler(w http.ResponseWriter, r *http.Request)
+	// LoginHandler issue session token for user credentials
+	LoginHandler(w http.ResponseWriter, r *http.Request)
+	// LogoutHandler delete current session
+	LogoutHandler(w http.ResponseWriter, r *http.Request)
+	// GetMeHandler get current user
+	GetMeHandler(w http.ResponseWriter, r *http.Request)
+	// CreateApiKeyHandler create api key of current user
+	CreateApiKeyHandler(w http.ResponseWriter, r *http.Request)
+	// GetApiKeysHandler get api keys of current user
+	GetApiKeysHandler(w http.ResponseWriter, r *http.Request)
+	// DeleteApiKeyHandler delete api key of current user
+	DeleteApiKeyHandler(w http.ResponseWriter, r *http.Request)
+	// SetUserRoleHandler change role of the user
+	SetUserRoleHandler(w http.ResponseWriter, r *http.Request)
+	// CreateCalendarTokenHandler create or replace calendar feed token of current user
+	CreateCalendarTokenHandler(w http.ResponseWriter, r *http.Request)
+	// DeleteCalendarTokenHandler delete calendar feed token of current user
+	DeleteCalendarTokenHandler(w http.ResponseWriter, r *http.Request)
 }
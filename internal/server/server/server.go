@@ -1,34 +1,55 @@
 package server
 
 import (
+	"context"
 	"fmt"
 	"github.com/go-chi/chi"
 	"log/slog"
 	"net/http"
-	"os"
+	"sync"
+	"time"
 	"web/internal/config"
 	"web/internal/server/server/interfaces"
 	"web/internal/storage"
-	"web/storage/tags-list"
+)
+
+// default timeouts of http server if they are not set in config
+const (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultReadTimeout       = time.Minute
+	defaultWriteTimeout      = 2 * time.Minute
+	defaultIdleTimeout       = 2 * time.Minute
+	defaultShutdownTimeout   = 30 * time.Second
 )
 
 type Server struct {
 	Router   chi.Router
 	Handlers handlerInterfaces.HandlerMethods
 	Db       storage.Storage
-	Log      *slog.Logger
-	AllTags  *tagsList.TagsList
+	Blobs    storage.BlobStore
+	// BlobsMu serializes committing new blobs and deleting unused blobs,
+	// so blob is not deleted between commit of its content and creation of its attachment
+	BlobsMu sync.Mutex
+	Log     *slog.Logger
+	Cfg     *config.Config
+
+	// workers background workers started by Go, they run until stopWorkers is called on shutdown
+	workers     sync.WaitGroup
+	workersCtx  context.Context
+	stopWorkers context.CancelFunc
 }
 
 // NewServer create new http server
-func NewServer(db *storage.Storage, log *slog.Logger) *Server {
-	allTags := tagsList.NewTagsMemoryList(db, log)
-
+func NewServer(cfg *config.Config, db *storage.Storage, blobs storage.BlobStore, log *slog.Logger) *Server {
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
 	return &Server{
-		Router:  chi.NewRouter(),
-		Db:      *db,
-		Log:     log,
-		AllTags: allTags,
+		Router:      chi.NewRouter(),
+		Db:          *db,
+		Blobs:       blobs,
+		Log:         log,
+		Cfg:         cfg,
+		workersCtx:  workersCtx,
+		stopWorkers: stopWorkers,
 	}
 }
 
@@ -36,15 +57,70 @@ func (s *Server) InitHandlers(handlers handlerInterfaces.HandlerMethods) {
 	s.Handlers = handlers
 }
 
-// Start start http server
-func (s *Server) Start(cfg *config.Config, log *slog.Logger) {
+// Go runs background worker until the server is stopped, worker must return when ctx is done
+func (s *Server) Go(worker func(ctx context.Context)) {
+	s.workers.Add(1)
+	go func() {
+		defer s.workers.Done()
+		worker(s.workersCtx)
+	}()
+}
+
+// Start starts http server and serves requests until ctx is done, then shuts down in order:
+// new connections are refused, in-flight requests are finished within shutdown timeout,
+// background workers are stopped and database is closed.
+// Returns error if server can't start or in-flight requests are not finished in time
+func (s *Server) Start(ctx context.Context) error {
 	const op = "httpserver.Server.Start"
 
-	log.Info("Starting server", slog.String("host", cfg.Host), slog.String("port", cfg.Port))
-	err := http.ListenAndServe(cfg.Host+":"+cfg.Port, s.Router)
-	if err != nil {
-		log.Error(fmt.Sprintf("%v: %v", op, err.Error()))
-		os.Exit(1)
+	cfg := s.Cfg.Server
+	httpServer := &http.Server{
+		Addr:              cfg.Host + ":" + cfg.Port,
+		Handler:           s.Router,
+		ReadHeaderTimeout: orDefault(cfg.ReadHeaderTimeout, defaultReadHeaderTimeout),
+		ReadTimeout:       orDefault(cfg.ReadTimeout, defaultReadTimeout),
+		WriteTimeout:      orDefault(cfg.WriteTimeout, defaultWriteTimeout),
+		IdleTimeout:       orDefault(cfg.IdleTimeout, defaultIdleTimeout),
+		ErrorLog:          slog.NewLogLogger(s.Log.Handler(), slog.LevelError),
+	}
+
+	s.Log.Info("Starting server", slog.String("host", cfg.Host), slog.String("port", cfg.Port))
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+
+	var err error
+	select {
+	case err = <-serveErr:
+		err = fmt.Errorf("%v: %v", op, err.Error())
+	case <-ctx.Done():
+		s.Log.Info("Shutting down server, finishing in-flight requests")
+		timeout := orDefault(cfg.ShutdownTimeout, defaultShutdownTimeout)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		if err = httpServer.Shutdown(shutdownCtx); err != nil {
+			// requests which are not finished in time are closed
+			_ = httpServer.Close()
+			err = fmt.Errorf("%v: in-flight requests are not finished in %v, they are closed: %v", op, timeout, err.Error())
+		}
 	}
 
+	s.stopWorkers()
+	s.workers.Wait()
+	if closeErr := s.Db.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("%v: %v", op, closeErr.Error())
+	}
+	if err == nil {
+		s.Log.Info("Server is stopped")
+	}
+	return err
+}
+
+// orDefault returns timeout from config or default timeout if it is not set
+func orDefault(timeout, defaultTimeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return defaultTimeout
+	}
+	return timeout
 }
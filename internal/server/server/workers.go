@@ -0,0 +1,55 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// defaultPurgeInterval interval of trash auto-purge if it is not set in config
+const defaultPurgeInterval = time.Hour
+
+// DeleteUnusedBlobs deletes blobs of deleted attachments from blob store.
+// Errors are only logged, attachments are already deleted, so blobs left by error are never returned
+func (s *Server) DeleteUnusedBlobs() {
+	s.BlobsMu.Lock()
+	defer s.BlobsMu.Unlock()
+
+	hashes, err := s.Db.DeleteUnusedBlobs()
+	if err != nil {
+		return
+	}
+	for _, hash := range hashes {
+		_ = s.Blobs.Delete(hash)
+	}
+}
+
+// PurgeTrash deletes tasks and tags trashed earlier than retention from config forever,
+// it runs every purge interval until ctx is done. Auto-purge is disabled if retention is not set
+func (s *Server) PurgeTrash(ctx context.Context) {
+	retention := s.Cfg.Trash.Retention
+	if retention <= 0 {
+		s.Log.Info("Trash auto-purge is disabled")
+		return
+	}
+	interval := s.Cfg.Trash.PurgeInterval
+	if interval <= 0 {
+		interval = defaultPurgeInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		count, err := s.Db.PurgeTrash(time.Now().Add(-retention))
+		if err == nil && count > 0 {
+			s.DeleteUnusedBlobs()
+			s.Log.Info("Purged trash", slog.Int("count", count))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,245 @@
+'use strict';
+
+// session token from /user/login, kept only for the browser tab
+const tokenKey = 'token';
+
+const $ = (id) => document.getElementById(id);
+
+// ApiError error response of the API
+class ApiError extends Error {
+    constructor(status, message) {
+        super(message);
+        this.status = status;
+    }
+}
+
+// api sends request to the JSON route and returns data of the response
+async function api(method, path, body) {
+    const headers = {};
+    const token = sessionStorage.getItem(tokenKey);
+    if (token) {
+        headers.Authorization = 'Bearer ' + token;
+    }
+    if (body !== undefined) {
+        headers['Content-Type'] = 'application/json';
+    }
+    const resp = await fetch(path, {method, headers, body: body === undefined ? undefined : JSON.stringify(body)});
+    const json = await resp.json().catch(() => ({}));
+    if (!resp.ok) {
+        if (resp.status === 401 && token) {
+            sessionStorage.removeItem(tokenKey);
+            show();
+        }
+        throw new ApiError(resp.status, json.error || resp.statusText);
+    }
+    return json.data;
+}
+
+function message(text, isError) {
+    const el = $('message');
+    el.textContent = text;
+    el.classList.toggle('error', Boolean(isError));
+    el.hidden = !text;
+}
+
+// run runs action of the UI and shows its error
+async function run(action) {
+    try {
+        message('');
+        await action();
+    } catch (err) {
+        message(err.message, true);
+    }
+}
+
+function element(tag, text) {
+    const el = document.createElement(tag);
+    if (text !== undefined) {
+        el.textContent = text;
+    }
+    return el;
+}
+
+function button(text, onClick) {
+    const el = element('button', text);
+    el.type = 'button';
+    el.addEventListener('click', () => run(onClick));
+    return el;
+}
+
+// splitList returns not empty values separated by ','
+function splitList(value) {
+    return value.split(',').map((item) => item.trim()).filter((item) => item !== '');
+}
+
+// tasksPath returns route and query of tasks matching filter form
+function tasksPath() {
+    const form = new FormData($('filter'));
+    const query = new URLSearchParams();
+    const tags = splitList(form.get('tag'));
+    let path = '/task/';
+    if (tags.length > 0) {
+        path = '/task/tag/' + (form.get('mode') ? form.get('mode') + '/' : '');
+        query.set('tag', tags.join(','));
+    }
+    for (const name of ['priority', 'sort']) {
+        if (form.get(name)) {
+            query.set(name, form.get(name));
+        }
+    }
+    // date of the filter is midnight UTC
+    for (const name of ['due_from', 'due_to']) {
+        if (form.get(name)) {
+            query.set(name, form.get(name) + 'T00:00:00Z');
+        }
+    }
+    return query.toString() ? path + '?' + query : path;
+}
+
+function dueText(due) {
+    const date = new Date(due);
+    if (isNaN(date)) {
+        return due;
+    }
+    if (date.getUTCHours() === 0 && date.getUTCMinutes() === 0 && date.getUTCSeconds() === 0) {
+        return due.slice(0, 10);
+    }
+    return date.toLocaleString();
+}
+
+async function loadTasks() {
+    let tasks = [];
+    try {
+        tasks = (await api('GET', tasksPath())).tasks || [];
+    } catch (err) {
+        // no matching tasks
+        if (err.status !== 404) {
+            throw err;
+        }
+    }
+
+    const rows = tasks.map((task) => {
+        const row = element('tr');
+        row.classList.toggle('done', task.done);
+        row.classList.toggle('overdue', !task.done && new Date(task.due) < new Date());
+
+        const done = element('input');
+        done.type = 'checkbox';
+        done.checked = task.done;
+        done.addEventListener('change', () => run(async () => {
+            await api('PATCH', '/task/' + task.id, {done: done.checked});
+            await loadTasks();
+        }));
+        const check = element('td');
+        check.append(done);
+
+        const actions = element('td');
+        actions.append(button('Delete', async () => {
+            await api('DELETE', '/task/' + task.id);
+            message('Task moved to trash');
+            await loadTasks();
+        }));
+
+        row.append(check, element('td', task.text), element('td', (task.tags || []).join(', ')),
+            element('td', dueText(task.due)), element('td', task.priority), actions);
+        return row;
+    });
+    $('tasks').replaceChildren(...rows);
+    $('task-count').textContent = '(' + tasks.length + ')';
+}
+
+async function loadTags() {
+    const tags = (await api('GET', '/tag/')).tags || [];
+    const items = tags.map((tag) => {
+        const item = element('li');
+        item.append(button('#' + tag.name, async () => {
+            $('filter').elements.tag.value = tag.name;
+            await loadTasks();
+        }), button('×', async () => {
+            await api('DELETE', '/tag/' + encodeURIComponent(tag.name));
+            message('Tag moved to trash');
+            await loadTags();
+        }));
+        return item;
+    });
+    $('tags').replaceChildren(...items);
+}
+
+// show shows login form without session, tasks and tags with session
+async function show() {
+    const loggedIn = Boolean(sessionStorage.getItem(tokenKey));
+    $('login-view').hidden = loggedIn;
+    $('app-view').hidden = !loggedIn;
+    $('account').hidden = !loggedIn;
+    if (!loggedIn) {
+        return;
+    }
+    await run(async () => {
+        const user = await api('GET', '/user/me');
+        $('user').textContent = user.name + ' (' + user.role + ')';
+        await Promise.all([loadTasks(), loadTags()]);
+    });
+}
+
+$('login').addEventListener('submit', (event) => {
+    event.preventDefault();
+    const form = new FormData(event.target);
+    run(async () => {
+        const session = await api('POST', '/user/login', {name: form.get('name'), password: form.get('password')});
+        sessionStorage.setItem(tokenKey, session.token);
+        event.target.reset();
+        await show();
+    });
+});
+
+$('logout').addEventListener('click', () => run(async () => {
+    try {
+        await api('POST', '/user/logout');
+    } finally {
+        sessionStorage.removeItem(tokenKey);
+        await show();
+    }
+}));
+
+$('filter').addEventListener('submit', (event) => {
+    event.preventDefault();
+    run(loadTasks);
+});
+
+$('filter').addEventListener('reset', () => {
+    // form fields are cleared after reset event
+    setTimeout(() => run(loadTasks));
+});
+
+$('new-task').addEventListener('submit', (event) => {
+    event.preventDefault();
+    const form = new FormData(event.target);
+    run(async () => {
+        const task = {
+            text: form.get('text'),
+            tags: splitList(form.get('tags')),
+            // local time of the form to RFC3339 without milliseconds
+            due: new Date(form.get('due')).toISOString().replace(/\.\d{3}Z$/, 'Z'),
+        };
+        if (form.get('priority')) {
+            task.priority = form.get('priority');
+        }
+        const created = await api('POST', '/task/', task);
+        message('Task ' + created.id + ' created');
+        event.target.reset();
+        await loadTasks();
+    });
+});
+
+$('new-tag').addEventListener('submit', (event) => {
+    event.preventDefault();
+    const form = new FormData(event.target);
+    run(async () => {
+        await api('POST', '/tag/', {name: form.get('name')});
+        message('Tag ' + form.get('name') + ' created');
+        event.target.reset();
+        await loadTags();
+    });
+});
+
+show();
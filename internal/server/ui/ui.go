@@ -0,0 +1,29 @@
+// Package ui is minimal web UI of the API, static files are embedded into the server binary.
+// UI logs in by /user/login and calls JSON routes with session token, so it has the same auth as other clients
+package ui
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// Path path of the UI, index page is Path
+const Path = "/ui/"
+
+//go:embed static
+var static embed.FS
+
+// Handler serves static files of the UI under Path, files are revalidated on each load
+func Handler() http.Handler {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		// static directory is embedded at build time
+		panic(err)
+	}
+	server := http.StripPrefix(Path, http.FileServer(http.FS(files)))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		server.ServeHTTP(w, r)
+	})
+}
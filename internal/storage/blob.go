@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"io"
+	"log/slog"
+	"web/internal/config"
+)
+
+// BlobStore - storage of attachment files.
+// Blob is stored under sha256 hash of its content, so same content is stored once.
+type BlobStore interface {
+	// Connect opens blob store.
+	// cfg *config.Config - configuration of blob store.
+	// log *slog.Logger - logger.
+	Connect(cfg *config.Config, log *slog.Logger) BlobStore
+
+	// Stage writes content of the reader to staging area, content is not staged if reading fails.
+	// Staged blob can't be opened, it must be committed or discarded.
+	Stage(r io.Reader) (*Blob, error)
+
+	// Commit moves staged blob under its hash, New is set if content was not stored before.
+	Commit(blob *Blob) error
+
+	// Discard deletes staged content of the blob, committed blob is not changed.
+	Discard(blob *Blob)
+
+	// Open returns content of the blob by hash.
+	Open(hash string) (io.ReadSeekCloser, error)
+
+	// Delete deletes blob by hash, missing blob is not an error.
+	Delete(hash string) error
+}
+
+// Blob stored content, New - content was not stored before, Staged - name of staged content, empty after Commit
+type Blob struct {
+	Hash   string
+	Size   int64
+	New    bool
+	Staged string
+}
@@ -0,0 +1,126 @@
+package filesystem
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"web/internal/config"
+	"web/internal/storage"
+)
+
+// BlobStoreFs blobs in local directory, blob is file named by hash in subdirectory of first two hash chars
+type BlobStoreFs struct {
+	Dir string
+	Log *slog.Logger
+}
+
+// Connect creates directory of blobs from config
+func (b *BlobStoreFs) Connect(cfg *config.Config, log *slog.Logger) storage.BlobStore {
+	const op = "filesystem.Connect"
+
+	dir := cfg.BlobStore.Config["path"]
+	if dir == "" {
+		log.Error(fmt.Sprintf("%v: path of blob store is not set", op))
+		os.Exit(1)
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		log.Error(fmt.Sprintf("%v: %v", op, err.Error()))
+		os.Exit(1)
+	}
+	return &BlobStoreFs{Dir: dir, Log: log}
+}
+
+func (b *BlobStoreFs) Stage(r io.Reader) (*storage.Blob, error) {
+	const op = "filesystem.Stage"
+
+	// content is written to temp file first, so half written blob never gets hash name
+	tmp, err := os.CreateTemp(b.Dir, "upload-*")
+	if err != nil {
+		b.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), r)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return nil, err
+	}
+	return &storage.Blob{Hash: hex.EncodeToString(hash.Sum(nil)), Size: size, Staged: filepath.Base(tmp.Name())}, nil
+}
+
+func (b *BlobStoreFs) Commit(blob *storage.Blob) error {
+	const op = "filesystem.Commit"
+
+	staged := filepath.Join(b.Dir, blob.Staged)
+	path := b.path(blob.Hash)
+	if _, err := os.Stat(path); err == nil {
+		b.Discard(blob)
+		return nil
+	}
+	err := os.MkdirAll(filepath.Dir(path), 0o750)
+	if err == nil {
+		err = os.Rename(staged, path)
+	}
+	if err != nil {
+		b.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	blob.New = true
+	blob.Staged = ""
+	return nil
+}
+
+func (b *BlobStoreFs) Discard(blob *storage.Blob) {
+	if blob.Staged == "" {
+		return
+	}
+	_ = os.Remove(filepath.Join(b.Dir, blob.Staged))
+	blob.Staged = ""
+}
+
+func (b *BlobStoreFs) Open(hash string) (io.ReadSeekCloser, error) {
+	if !validHash(hash) {
+		return nil, fmt.Errorf("invalid blob hash '%s'", hash)
+	}
+	return os.Open(b.path(hash))
+}
+
+func (b *BlobStoreFs) Delete(hash string) error {
+	const op = "filesystem.Delete"
+
+	if !validHash(hash) {
+		return fmt.Errorf("invalid blob hash '%s'", hash)
+	}
+	err := os.Remove(b.path(hash))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		b.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (b *BlobStoreFs) path(hash string) string {
+	return filepath.Join(b.Dir, hash[:2], hash)
+}
+
+// validHash reports whether hash is hex sha256, so it can't escape directory of blobs
+func validHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
@@ -1,14 +1,29 @@
 package storage
 
 import (
+	"encoding/json"
+	"fmt"
 	"strings"
+	"time"
 )
 
 type Task struct {
-	Id   int      `json:"id"`
-	Text string   `json:"text"`
-	Tags []string `json:"tags"`
-	Due  string   `json:"due"`
+	Id        int      `json:"id"`
+	Text      string   `json:"text"`
+	Tags      []string `json:"tags"`
+	Due       string   `json:"due"`
+	ProjectId int      `json:"project_id,omitempty"`
+	Assignee  string   `json:"assignee,omitempty"`
+	Priority  string   `json:"priority"`
+	ParentId  int      `json:"parent_id,omitempty"`
+	Done      bool     `json:"done"`
+	// Blocked task has not done blockers
+	Blocked bool `json:"blocked"`
+	// Position user defined order of tasks, see SortPosition
+	Position     float64 `json:"position"`
+	CommentCount int     `json:"comment_count"`
+	// DeletedAt time the task was moved to trash, empty for not trashed task
+	DeletedAt string `json:"deleted_at,omitempty"`
 }
 
 func NewTask(id int, text, tags, due string) *Task {
@@ -22,14 +37,249 @@ func NewTask(id int, text, tags, due string) *Task {
 	}
 }
 
+// priorities names of task priorities, index of name is priority stored in database, P0 - the most important
+var priorities = []string{"P0", "P1", "P2", "P3", "P4"}
+
+// DefaultPriority priority of the task created without priority, P2
+const DefaultPriority = 2
+
+// ParsePriority returns priority by name: P0-P4
+func ParsePriority(name string) (int, error) {
+	for priority, priorityName := range priorities {
+		if strings.EqualFold(name, priorityName) {
+			return priority, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown priority '%s', expect one of: %s", name, strings.Join(priorities, ", "))
+}
+
+// PriorityName returns name of priority
+func PriorityName(priority int) string {
+	if priority < 0 || priority >= len(priorities) {
+		return ""
+	}
+	return priorities[priority]
+}
+
 type Tasks struct {
 	Total int    `json:"total"`
 	Tasks []Task `json:"tasks"`
 }
 
-type Tag struct {
+// TaskFilter optional conditions of task queries, zero value of field means no condition
+type TaskFilter struct {
+	// ProjectId returns only tasks of the project
+	ProjectId int
+	// Assignee returns only tasks assigned to the user with this name
+	Assignee string
+	// Priorities returns only tasks with one of priorities
+	Priorities []int
+	// Sort order of tasks, see SortPriority, SortDue and SortPosition, empty - order is not defined
+	Sort string
+	// Ready returns only not done tasks without not done blockers
+	Ready bool
+	// DueFrom and DueTo return only tasks due from DueFrom inclusive to DueTo exclusive, nil - no bound
+	DueFrom *time.Time
+	DueTo   *time.Time
+}
+
+// Sort orders of tasks
+const (
+	// SortPriority the most important tasks first, tasks with same priority by due date
+	SortPriority = "priority"
+	// SortDue tasks by due date
+	SortDue = "due"
+	// SortPosition user defined order, see Storage.MoveTask
+	SortPosition = "position"
+)
+
+// TaskCreate fields of new task, empty Assignee - task is not assigned
+type TaskCreate struct {
+	ProjectId int
+	Text      string
+	Tags      []string
+	Due       *time.Time
+	Assignee  string
+	Priority  int
+	// ParentId parent task in the same project, 0 - top level task
+	ParentId int
+}
+
+// TaskUpdate fields of the task to change, nil field is not changed.
+// Empty Assignee removes assignee of the task
+type TaskUpdate struct {
+	Text     *string
+	Tags     []string
+	Due      *time.Time
+	Assignee *string
+	Priority *int
+	// ParentId 0 moves task to the top level
+	ParentId *int
+	Done     *bool
+}
+
+// Dependency blocker task blocks blocked task until blocker is done
+type Dependency struct {
+	BlockerId int `json:"blocker_id"`
+	BlockedId int `json:"blocked_id"`
+}
+
+// DependencyGraph tasks with dependencies and dependencies between them
+type DependencyGraph struct {
+	Tasks        []Task       `json:"tasks"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// Comment markdown text of the user on the task
+type Comment struct {
+	Id        int    `json:"id"`
+	Author    string `json:"author"`
+	Text      string `json:"text"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type Comments struct {
+	Comments []Comment `json:"comments"`
+}
+
+// Attachment file of the task, content is in BlobStore under Hash
+type Attachment struct {
+	Id        int    `json:"id"`
+	Author    string `json:"author"`
+	Name      string `json:"name"`
+	MimeType  string `json:"mime_type"`
+	Size      int64  `json:"size"`
+	Hash      string `json:"sha256"`
+	CreatedAt string `json:"created_at"`
+}
+
+type Attachments struct {
+	Attachments []Attachment `json:"attachments"`
+}
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditMove   = "move"
+	AuditDelete = "delete"
+	// AuditRestore entity is restored from trash
+	AuditRestore = "restore"
+	// AuditPurge entity is deleted from trash forever
+	AuditPurge = "purge"
+)
+
+// Audit entities, changes of task parts are entries of the task too, see AuditEntry.TaskId
+const (
+	EntityTask          = "task"
+	EntityTag           = "tag"
+	EntityProject       = "project"
+	EntityComment       = "comment"
+	EntityAttachment    = "attachment"
+	EntityChecklistItem = "checklist_item"
+	EntityDependency    = "dependency"
+	EntityShare         = "share"
+)
+
+// AuditEntry change of the entity made by Actor, empty Actor - change made by server, e.g. trash auto-purge.
+// Before and After - json snapshots of the entity,
+// Before is empty for created entity and After is empty for deleted entity
+type AuditEntry struct {
+	Id        int             `json:"id"`
+	Actor     string          `json:"actor"`
+	Action    string          `json:"action"`
+	Entity    string          `json:"entity"`
+	EntityId  int             `json:"entity_id"`
+	TaskId    int             `json:"task_id,omitempty"`
+	Before    json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After     json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	CreatedAt string          `json:"created_at"`
+	// Operation token of undoable operation the change belongs to
+	Operation string `json:"operation,omitempty"`
+}
+
+type AuditLog struct {
+	Entries []AuditEntry `json:"entries"`
+}
+
+// AuditFilter optional conditions of audit log query, zero value of field means no condition
+type AuditFilter struct {
+	// Actor returns only changes made by the user with this name
+	Actor    string
+	Entity   string
+	EntityId int
+	// TaskId returns changes of the task and its parts
+	TaskId int
+	From   *time.Time
+	To     *time.Time
+	// Limit max number of the newest entries
+	Limit int
+}
+
+// ChecklistItem lightweight step of the task
+type ChecklistItem struct {
 	Id   int    `json:"id"`
-	Name string `json:"name"`
+	Text string `json:"text"`
+	Done bool   `json:"done"`
+}
+
+type Checklist struct {
+	Items []ChecklistItem `json:"items"`
+}
+
+// TaskTree task with its checklist and subtasks of any depth
+type TaskTree struct {
+	Task      Task            `json:"task"`
+	Checklist []ChecklistItem `json:"checklist"`
+	Subtasks  []TaskTree      `json:"subtasks"`
+}
+
+// Progress done part of the task tree: subtasks and checklist items of all levels
+type Progress struct {
+	Total   int `json:"total"`
+	Done    int `json:"done"`
+	Percent int `json:"percent"`
+}
+
+// Progress counts done subtasks and checklist items of the tree,
+// task without subtasks and checklist is 100% done if it is done
+func (t *TaskTree) Progress() *Progress {
+	var progress Progress
+	t.count(&progress)
+
+	switch {
+	case progress.Total > 0:
+		progress.Percent = progress.Done * 100 / progress.Total
+	case t.Task.Done:
+		progress.Percent = 100
+	}
+	return &progress
+}
+
+// count adds checklist items and subtasks of all levels to progress
+func (t *TaskTree) count(progress *Progress) {
+	for _, item := range t.Checklist {
+		progress.Total++
+		if item.Done {
+			progress.Done++
+		}
+	}
+	for i := range t.Subtasks {
+		progress.Total++
+		if t.Subtasks[i].Task.Done {
+			progress.Done++
+		}
+		t.Subtasks[i].count(progress)
+	}
+}
+
+type Tag struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	ProjectId int    `json:"project_id,omitempty"`
+	// DeletedAt time the tag was moved to trash, empty for not trashed tag
+	DeletedAt string `json:"deleted_at,omitempty"`
 }
 
 func NewTag(id int, name string) *Tag {
@@ -42,3 +292,103 @@ func NewTag(id int, name string) *Tag {
 type Tags struct {
 	Tags []Tag `json:"tags"`
 }
+
+// Trash deleted tasks and tags, they can be restored until they are purged
+type Trash struct {
+	Tasks []Task `json:"tasks"`
+	Tags  []Tag  `json:"tags"`
+}
+
+// User roles, see web/internal/server/policy for permissions of each role
+const (
+	RoleAdmin    = "admin"
+	RoleMember   = "member"
+	RoleReadOnly = "read-only"
+)
+
+// roleRank role with higher rank has more permissions
+var roleRank = map[string]int{RoleReadOnly: 1, RoleMember: 2, RoleAdmin: 3}
+
+// ValidRole reports whether role is known
+func ValidRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+// WeakerRole returns role with fewer permissions
+func WeakerRole(a, b string) string {
+	if roleRank[a] < roleRank[b] {
+		return a
+	}
+	return b
+}
+
+type Project struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Owner     string `json:"owner"`
+	CreatedAt string `json:"created_at"`
+}
+
+type Projects struct {
+	Projects []Project `json:"projects"`
+}
+
+type User struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
+}
+
+func NewUser(id int, name, role, createdAt string) *User {
+	return &User{
+		Id:        id,
+		Name:      name,
+		Role:      role,
+		CreatedAt: createdAt,
+	}
+}
+
+type Session struct {
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// ApiKey long-lived key of the user, requests with key have role of the key instead of user role
+type ApiKey struct {
+	Id        int    `json:"id"`
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	Key       string `json:"key,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ApiKeys struct {
+	Keys []ApiKey `json:"keys"`
+}
+
+// Share permissions, read - user can see task or tasks of the project, write - user can change them too
+const (
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+)
+
+// Share grant of the task or project to the user
+type Share struct {
+	User       string `json:"user"`
+	Permission string `json:"permission"`
+}
+
+type Shares struct {
+	Shares []Share `json:"shares"`
+}
+
+// TagMismatch task whose tags differ from its links in task_tags, tags and links are sorted.
+// Missing - links belong to task which doesn't exist
+type TagMismatch struct {
+	TaskId  int      `json:"task_id"`
+	Tags    []string `json:"tags"`
+	Links   []string `json:"links"`
+	Missing bool     `json:"missing"`
+}
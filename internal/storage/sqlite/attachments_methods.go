@@ -0,0 +1,151 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+// attachmentColumns columns of storage.Attachment, attachments table alias must be a
+const attachmentColumns = `a.id, (SELECT name FROM users WHERE id = a.user_id), a.name, a.mime_type,
+	(SELECT size FROM blobs WHERE hash = a.hash), a.hash, a.created_at`
+
+func scanAttachment(row interface{ Scan(dest ...any) error }) (*storage.Attachment, error) {
+	var attachment storage.Attachment
+	err := row.Scan(&attachment.Id, &attachment.Author, &attachment.Name, &attachment.MimeType,
+		&attachment.Size, &attachment.Hash, &attachment.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (s *StoreSqlite) GetAttachments(userId int, taskId int) (*storage.Attachments, error) {
+	const op = "sqlite.GetAttachments"
+
+	if err := s.checkTaskAccess(userId, taskId, accessRead); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db().Query(fmt.Sprintf(`SELECT %s FROM attachments a WHERE a.task_id = ? ORDER BY a.id`,
+		attachmentColumns), taskId)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := storage.Attachments{Attachments: []storage.Attachment{}}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		attachments.Attachments = append(attachments.Attachments, *attachment)
+	}
+	return &attachments, nil
+}
+
+func (s *StoreSqlite) GetAttachment(userId int, taskId int, id int) (*storage.Attachment, error) {
+	const op = "sqlite.GetAttachment"
+
+	if err := s.checkTaskAccess(userId, taskId, accessRead); err != nil {
+		return nil, err
+	}
+
+	attachment, err := scanAttachment(s.db().QueryRow(fmt.Sprintf(
+		`SELECT %s FROM attachments a WHERE a.id = ? AND a.task_id = ?`, attachmentColumns), id, taskId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrorSqliteNew(http.StatusNotFound, "attachment not found")
+	}
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (s *StoreSqlite) CreateAttachment(userId int, taskId int, name, mimeType string, blob *storage.Blob) (*storage.Attachment, error) {
+	const op = "sqlite.CreateAttachment"
+
+	if err := s.checkTaskAccess(userId, taskId, accessWrite); err != nil {
+		return nil, err
+	}
+
+	var attachment *storage.Attachment
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		var id int
+		_, err := tx.Exec(`INSERT OR IGNORE INTO blobs (hash, size) VALUES (?, ?)`, blob.Hash, blob.Size)
+		if err != nil {
+			return err
+		}
+		err = tx.QueryRow(`INSERT INTO attachments (task_id, user_id, name, mime_type, hash) VALUES (?, ?, ?, ?, ?)
+				RETURNING id`, taskId, userId, name, mimeType, blob.Hash).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		attachment, err = scanAttachment(tx.QueryRow(fmt.Sprintf(
+			`SELECT %s FROM attachments a WHERE a.id = ?`, attachmentColumns), id))
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, userId, storage.AuditCreate, storage.EntityAttachment, id, taskId, nil, attachment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (s *StoreSqlite) DeleteAttachment(userId int, taskId int, id int) error {
+	const op = "sqlite.DeleteAttachment"
+
+	if err := s.checkTaskAccess(userId, taskId, accessWrite); err != nil {
+		return err
+	}
+
+	return s.inTx(op, func(tx *sql.Tx) error {
+		attachment, err := scanAttachment(tx.QueryRow(fmt.Sprintf(`
+					SELECT %s FROM attachments a WHERE a.id = ? AND a.task_id = ?
+					AND (a.user_id = ? OR a.task_id IN (SELECT id FROM tasks WHERE user_id = ?))
+				`, attachmentColumns), id, taskId, userId, userId))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrorSqliteNew(http.StatusNotFound, "attachment not found")
+		}
+		if err == nil {
+			err = s.audit(tx, userId, storage.AuditDelete, storage.EntityAttachment, id, taskId, attachment, nil)
+		}
+		if err == nil {
+			_, err = tx.Exec(`DELETE FROM attachments WHERE id = ?`, id)
+		}
+		return err
+	})
+}
+
+func (s *StoreSqlite) DeleteUnusedBlobs() ([]string, error) {
+	const op = "sqlite.DeleteUnusedBlobs"
+
+	rows, err := s.db().Query(`DELETE FROM blobs WHERE hash NOT IN (SELECT hash FROM attachments) RETURNING hash`)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
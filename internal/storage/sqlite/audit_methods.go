@@ -0,0 +1,162 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+// defaultAuditLimit number of entries returned by GetAuditLog without limit
+const defaultAuditLimit = 100
+
+// auditTimeFormat format of created_at column, CURRENT_TIMESTAMP is in UTC
+const auditTimeFormat = "2006-01-02 15:04:05"
+
+// auditColumns columns of storage.AuditEntry, audit_log table alias must be a, actor of server changes is empty
+const auditColumns = `a.id, COALESCE((SELECT name FROM users WHERE id = a.user_id), ''), a.action, a.entity, a.entity_id, a.task_id,
+	COALESCE(a.before_data, ''), COALESCE(a.after_data, ''), a.created_at, COALESCE(a.operation, '')`
+
+// audit records change of the entity by the user, nil before or after is not stored.
+// taskId - task of the entity or 0, its current owner becomes owner of the entry,
+// so deleted task is audited before it is deleted.
+// Change is a part of the operation of the storage, if storage is returned by Operation
+func (s *StoreSqlite) audit(q querier, userId int, action, entity string, entityId int, taskId int, before, after any) error {
+	beforeData, err := snapshot(before)
+	if err != nil {
+		return err
+	}
+	afterData, err := snapshot(after)
+	if err != nil {
+		return err
+	}
+
+	var operation *string
+	if s.operation != "" {
+		_, err = q.Exec(`INSERT INTO operations (token, user_id) VALUES (?, ?) ON CONFLICT (token) DO NOTHING`,
+			s.operation, userId)
+		if err != nil {
+			return err
+		}
+		operation = &s.operation
+	}
+
+	_, err = q.Exec(`
+				INSERT INTO audit_log (user_id, action, entity, entity_id, task_id, owner_id, before_data, after_data, operation)
+				VALUES (?, ?, ?, ?, ?, COALESCE((SELECT user_id FROM tasks WHERE id = ?), 0), ?, ?, ?)
+			`, userId, action, entity, entityId, taskId, taskId, beforeData, afterData, operation)
+	return err
+}
+
+// snapshot returns json of the entity, nil for nil entity
+func snapshot(entity any) (*string, error) {
+	if entity == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+	text := string(data)
+	return &text, nil
+}
+
+func (s *StoreSqlite) GetTaskHistory(userId int, taskId int) (*storage.AuditLog, error) {
+	const op = "sqlite.GetTaskHistory"
+
+	if err := s.checkTaskAccess(userId, taskId, accessRead); err != nil {
+		var errSql *ErrorSqlite
+		if !errors.As(err, &errSql) || errSql.Code != http.StatusNotFound {
+			return nil, err
+		}
+
+		// task ids are not reused, so not found task with delete entry is deleted task
+		var owner bool
+		err := s.db().QueryRow(`SELECT EXISTS (SELECT 1 FROM audit_log
+				WHERE task_id = ? AND entity = ? AND action = ? AND owner_id = ?)`,
+			taskId, storage.EntityTask, storage.AuditDelete, userId).Scan(&owner)
+		if err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		if !owner {
+			return nil, errSql
+		}
+	}
+
+	return s.auditLog(s.db(), op, fmt.Sprintf(`SELECT %s FROM audit_log a WHERE a.task_id = ? ORDER BY a.id`, auditColumns),
+		taskId)
+}
+
+func (s *StoreSqlite) GetAuditLog(filter *storage.AuditFilter) (*storage.AuditLog, error) {
+	const op = "sqlite.GetAuditLog"
+
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+	if filter.Actor != "" {
+		conditions = append(conditions, "a.user_id = (SELECT id FROM users WHERE name = ?)")
+		args = append(args, filter.Actor)
+	}
+	if filter.Entity != "" {
+		conditions = append(conditions, "a.entity = ?")
+		args = append(args, filter.Entity)
+	}
+	if filter.EntityId != 0 {
+		conditions = append(conditions, "a.entity_id = ?")
+		args = append(args, filter.EntityId)
+	}
+	if filter.TaskId != 0 {
+		conditions = append(conditions, "a.task_id = ?")
+		args = append(args, filter.TaskId)
+	}
+	if filter.From != nil {
+		conditions = append(conditions, "a.created_at >= ?")
+		args = append(args, filter.From.UTC().Format(auditTimeFormat))
+	}
+	if filter.To != nil {
+		conditions = append(conditions, "a.created_at < ?")
+		args = append(args, filter.To.UTC().Format(auditTimeFormat))
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = defaultAuditLimit
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM audit_log a WHERE %s ORDER BY a.id DESC LIMIT ?`,
+		auditColumns, strings.Join(conditions, " AND "))
+	return s.auditLog(s.db(), op, query, append(args, limit)...)
+}
+
+// auditLog returns entries selected with auditColumns, q - database or transaction
+func (s *StoreSqlite) auditLog(q querier, op string, query string, args ...interface{}) (*storage.AuditLog, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	log := storage.AuditLog{Entries: []storage.AuditEntry{}}
+	for rows.Next() {
+		var entry storage.AuditEntry
+		var before, after string
+		err := rows.Scan(&entry.Id, &entry.Actor, &entry.Action, &entry.Entity, &entry.EntityId, &entry.TaskId,
+			&before, &after, &entry.CreatedAt, &entry.Operation)
+		if err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		if before != "" {
+			entry.Before = json.RawMessage(before)
+		}
+		if after != "" {
+			entry.After = json.RawMessage(after)
+		}
+		log.Entries = append(log.Entries, entry)
+	}
+	return &log, nil
+}
@@ -0,0 +1,165 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// backupPages pages copied by one step of backup, database is not locked between steps
+const backupPages = 256
+
+// backupRetry pause before next step of backup if database is busy
+const backupRetry = 10 * time.Millisecond
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+func (s *StoreSqlite) Backup(w io.Writer) error {
+	const op = "sqlite.Backup"
+
+	path, err := tempDatabase(nil)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	defer os.Remove(path)
+
+	err = withDatabase(path, func(snapshot *sql.DB) error {
+		return copyDatabase(snapshot, s.DataBase, backupPages)
+	})
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(w, file)
+	return err
+}
+
+func (s *StoreSqlite) Restore(r io.Reader) error {
+	const op = "sqlite.Restore"
+
+	path, err := tempDatabase(r)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(path)
+
+	err = withDatabase(path, func(snapshot *sql.DB) error {
+		var version int
+		var check string
+		if err := snapshot.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+			return ErrorSqliteNew(http.StatusBadRequest, "snapshot is not sqlite database")
+		}
+		if err := snapshot.QueryRow(`PRAGMA integrity_check`).Scan(&check); err != nil || check != "ok" {
+			return ErrorSqliteNew(http.StatusBadRequest, "snapshot is corrupted")
+		}
+		switch {
+		case version == 0:
+			return ErrorSqliteNew(http.StatusBadRequest, "snapshot has no schema version")
+		case version > len(migrations):
+			return ErrorSqliteNew(http.StatusConflict, fmt.Sprintf(
+				"snapshot schema version %d is newer than database schema version %d", version, len(migrations)))
+		}
+
+		// snapshot of older schema version is migrated before it replaces database, so requests see only current schema
+		// and failed migration leaves database unchanged
+		snapshotStore := &StoreSqlite{DataBase: snapshot, Log: s.Log}
+		if err := snapshotStore.migrate(); err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return ErrorSqliteNew(http.StatusBadRequest, fmt.Sprintf("snapshot schema version %d can't be migrated", version))
+		}
+
+		// database is locked until all pages are copied, so requests never see partly restored data
+		return copyDatabase(s.DataBase, snapshot, -1)
+	})
+	if err != nil {
+		var errSql *ErrorSqlite
+		if !errors.As(err, &errSql) {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		}
+		return err
+	}
+	return nil
+}
+
+// tempDatabase returns path of new temporary file with content of r, empty file if r is nil
+func tempDatabase(r io.Reader) (string, error) {
+	file, err := os.CreateTemp("", "backup-*.db")
+	if err != nil {
+		return "", err
+	}
+	if r != nil {
+		_, err = io.Copy(file, r)
+	}
+	if errClose := file.Close(); err == nil {
+		err = errClose
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// withDatabase runs fn with opened sqlite database file
+func withDatabase(path string, fn func(db *sql.DB) error) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return fn(db)
+}
+
+// copyDatabase copies all pages of src database to dest database by sqlite online backup api,
+// pages - pages copied by one step, -1 - all pages in one step
+func copyDatabase(dest, src *sql.DB, pages int) error {
+	ctx := context.Background()
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(destDriver any) error {
+		return srcConn.Raw(func(srcDriver any) error {
+			backup, err := destDriver.(*sqlite3.SQLiteConn).Backup("main", srcDriver.(*sqlite3.SQLiteConn), "main")
+			if err != nil {
+				return err
+			}
+			for {
+				done, err := backup.Step(pages)
+				var errStep sqlite3.Error
+				if errors.As(err, &errStep) && (errStep.Code == sqlite3.ErrBusy || errStep.Code == sqlite3.ErrLocked) {
+					time.Sleep(backupRetry)
+					continue
+				}
+				if err != nil {
+					_ = backup.Finish()
+					return err
+				}
+				if done {
+					return backup.Finish()
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,78 @@
+package sqlite
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+func (s *StoreSqlite) CheckTaskTags() ([]storage.TagMismatch, error) {
+	const op = "sqlite.CheckTaskTags"
+
+	// tags column of tasks, trashed tasks keep their links too
+	tags := make(map[int][]string)
+	rows, err := s.db().Query(`SELECT id, COALESCE(tags, '') FROM tasks ORDER BY id`)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	var ids []int
+	for rows.Next() {
+		var id int
+		var column string
+		if err = rows.Scan(&id, &column); err != nil {
+			_ = rows.Close()
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		ids = append(ids, id)
+		tags[id] = nil
+		for _, tag := range strings.Split(column, "; ") {
+			if tag != "" {
+				tags[id] = append(tags[id], tag)
+			}
+		}
+		slices.Sort(tags[id])
+	}
+	if err = rows.Err(); err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+
+	links := make(map[int][]string)
+	rows, err = s.db().Query(`SELECT task_id, tag_name FROM task_tags ORDER BY task_id, tag_name`)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	for rows.Next() {
+		var id int
+		var name string
+		if err = rows.Scan(&id, &name); err != nil {
+			_ = rows.Close()
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		if _, ok := tags[id]; !ok && links[id] == nil {
+			ids = append(ids, id)
+		}
+		links[id] = append(links[id], name)
+	}
+	if err = rows.Err(); err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+
+	var mismatches []storage.TagMismatch
+	for _, id := range ids {
+		taskTags, ok := tags[id]
+		if ok && slices.Equal(taskTags, links[id]) {
+			continue
+		}
+		mismatches = append(mismatches, storage.TagMismatch{TaskId: id, Tags: taskTags, Links: links[id], Missing: !ok})
+	}
+	return mismatches, nil
+}
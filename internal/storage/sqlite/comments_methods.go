@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+// commentColumns columns of storage.Comment, comments table alias must be c
+const commentColumns = `c.id, (SELECT name FROM users WHERE id = c.user_id), c.text, c.created_at, c.updated_at`
+
+func (s *StoreSqlite) GetComments(userId int, taskId int) (*storage.Comments, error) {
+	const op = "sqlite.GetComments"
+
+	if err := s.checkTaskAccess(userId, taskId, accessRead); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db().Query(fmt.Sprintf(`SELECT %s FROM comments c WHERE c.task_id = ? ORDER BY c.id`,
+		commentColumns), taskId)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := storage.Comments{Comments: []storage.Comment{}}
+	for rows.Next() {
+		var comment storage.Comment
+		err := rows.Scan(&comment.Id, &comment.Author, &comment.Text, &comment.CreatedAt, &comment.UpdatedAt)
+		if err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		comments.Comments = append(comments.Comments, comment)
+	}
+	return &comments, nil
+}
+
+func (s *StoreSqlite) CreateComment(userId int, taskId int, text string) (*storage.Comment, error) {
+	const op = "sqlite.CreateComment"
+
+	if err := s.checkTaskAccess(userId, taskId, accessRead); err != nil {
+		return nil, err
+	}
+
+	var comment storage.Comment
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		err := tx.QueryRow(`
+					INSERT INTO comments (task_id, user_id, text) VALUES (?, ?, ?)
+					RETURNING id, (SELECT name FROM users WHERE id = user_id), text, created_at, updated_at
+				`, taskId, userId, text).
+			Scan(&comment.Id, &comment.Author, &comment.Text, &comment.CreatedAt, &comment.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, userId, storage.AuditCreate, storage.EntityComment, comment.Id, taskId, nil, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (s *StoreSqlite) DeleteComment(userId int, taskId int, id int) error {
+	const op = "sqlite.DeleteComment"
+
+	if err := s.checkTaskAccess(userId, taskId, accessRead); err != nil {
+		return err
+	}
+
+	return s.inTx(op, func(tx *sql.Tx) error {
+		var comment storage.Comment
+		err := tx.QueryRow(fmt.Sprintf(`
+					SELECT %s FROM comments c WHERE c.id = ? AND c.task_id = ?
+					AND (c.user_id = ? OR c.task_id IN (SELECT id FROM tasks WHERE user_id = ?))
+				`, commentColumns), id, taskId, userId, userId).
+			Scan(&comment.Id, &comment.Author, &comment.Text, &comment.CreatedAt, &comment.UpdatedAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrorSqliteNew(http.StatusNotFound, "comment not found")
+		}
+		if err == nil {
+			err = s.audit(tx, userId, storage.AuditDelete, storage.EntityComment, id, taskId, comment, nil)
+		}
+		if err == nil {
+			_, err = tx.Exec(`DELETE FROM comments WHERE id = ?`, id)
+		}
+		return err
+	})
+}
@@ -0,0 +1,132 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"net/http"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+func (s *StoreSqlite) AddDependency(userId int, blockerId int, blockedId int) error {
+	const op = "sqlite.AddDependency"
+
+	if blockerId == blockedId {
+		return ErrorSqliteNew(http.StatusBadRequest, "task can't block itself")
+	}
+	if err := s.checkTaskAccess(userId, blockedId, accessWrite); err != nil {
+		return err
+	}
+	if err := s.checkTaskAccess(userId, blockerId, accessRead); err != nil {
+		return err
+	}
+
+	// blockers of the blocker of any depth include blocked task if dependency makes cycle
+	var cycle bool
+	err := s.db().QueryRow(`
+				WITH RECURSIVE blockers(id) AS (
+					SELECT ?
+					UNION
+					SELECT d.blocker_id FROM task_dependencies d JOIN blockers b ON d.blocked_id = b.id
+				)
+				SELECT EXISTS (SELECT 1 FROM blockers WHERE id = ?)
+			`, blockerId, blockedId).Scan(&cycle)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	if cycle {
+		return ErrorSqliteNew(http.StatusBadRequest, "dependency makes cycle")
+	}
+
+	return s.inTx(op, func(tx *sql.Tx) error {
+		_, err := tx.Exec(`INSERT INTO task_dependencies (blocker_id, blocked_id) VALUES (?, ?)`, blockerId, blockedId)
+		if err != nil {
+			var errSql sqlite3.Error
+			if errors.As(err, &errSql) && errSql.ExtendedCode == sqlite3.ErrConstraintPrimaryKey {
+				return ErrorSqliteNew(http.StatusConflict, "dependency already exists")
+			}
+			return err
+		}
+		dependency := storage.Dependency{BlockerId: blockerId, BlockedId: blockedId}
+		return s.audit(tx, userId, storage.AuditCreate, storage.EntityDependency, blockerId, blockedId, nil, dependency)
+	})
+}
+
+func (s *StoreSqlite) RemoveDependency(userId int, blockerId int, blockedId int) error {
+	const op = "sqlite.RemoveDependency"
+
+	if err := s.checkTaskAccess(userId, blockedId, accessWrite); err != nil {
+		return err
+	}
+
+	return s.inTx(op, func(tx *sql.Tx) error {
+		result, err := tx.Exec(`DELETE FROM task_dependencies WHERE blocker_id = ? AND blocked_id = ?`,
+			blockerId, blockedId)
+		if err != nil {
+			return err
+		}
+		if count, _ := result.RowsAffected(); count == 0 {
+			return ErrorSqliteNew(http.StatusNotFound, "dependency not found")
+		}
+		dependency := storage.Dependency{BlockerId: blockerId, BlockedId: blockedId}
+		return s.audit(tx, userId, storage.AuditDelete, storage.EntityDependency, blockerId, blockedId, dependency, nil)
+	})
+}
+
+func (s *StoreSqlite) GetDependencyGraph(userId int, filter *storage.TaskFilter) (*storage.DependencyGraph, error) {
+	const op = "sqlite.GetDependencyGraph"
+
+	scope, scopeArgs := taskScope(userId, filter)
+	query := fmt.Sprintf(`
+				SELECT %s FROM tasks t1
+				WHERE %s AND EXISTS (SELECT 1 FROM task_dependencies d WHERE d.blocker_id = t1.id OR d.blocked_id = t1.id)
+			`, taskColumns, scope)
+	rows, err := s.db().Query(orderTasks(query, filter), scopeArgs...)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	graph := storage.DependencyGraph{Tasks: []storage.Task{}, Dependencies: []storage.Dependency{}}
+	nodes := make(map[int]bool)
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		graph.Tasks = append(graph.Tasks, *task)
+		nodes[task.Id] = true
+	}
+	if len(nodes) == 0 {
+		return &graph, nil
+	}
+
+	edges, err := s.db().Query(fmt.Sprintf(`
+				SELECT d.blocker_id, d.blocked_id FROM task_dependencies d JOIN tasks t1 ON t1.id = d.blocked_id
+				WHERE %s ORDER BY d.blocker_id, d.blocked_id
+			`, scope), scopeArgs...)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	defer edges.Close()
+
+	// only dependencies between selected tasks, other tasks are not visible or don't match filter
+	for edges.Next() {
+		var dependency storage.Dependency
+		if err := edges.Scan(&dependency.BlockerId, &dependency.BlockedId); err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		if nodes[dependency.BlockerId] && nodes[dependency.BlockedId] {
+			graph.Dependencies = append(graph.Dependencies, dependency)
+		}
+	}
+	return &graph, nil
+}
@@ -0,0 +1,274 @@
+package sqlite
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// migrations - ordered list of schema changes.
+// Schema version is stored in PRAGMA user_version, migration with index i upgrades schema to version i+1.
+// INFO: never edit applied migrations, append new one instead.
+var migrations = []string{
+	// 1: initial schema
+	`
+	CREATE TABLE IF NOT EXISTS tasks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		text TEXT NOT NULL,
+		tags TEXT,
+		due TIMESTAMP
+	);
+	CREATE TABLE IF NOT EXISTS tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE
+	);
+	CREATE TABLE IF NOT EXISTS task_tags (
+		task_id INT REFERENCES tasks(id),
+		tag_name VARCHAR(255) REFERENCES tags(name),
+		PRIMARY KEY (task_id, tag_name)
+	);
+	`,
+	// 2: users, sessions and owner of tasks and tags
+	`
+	CREATE TABLE users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		password_hash TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE TABLE sessions (
+		token_hash TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		expires_at TIMESTAMP NOT NULL
+	);
+	ALTER TABLE tasks ADD COLUMN user_id INTEGER REFERENCES users(id);
+	CREATE INDEX idx_tasks_user ON tasks(user_id);
+
+	-- tag names are uniq per user, sqlite can't drop constraint, so table is rebuilt
+	CREATE TABLE tags_new (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		user_id INTEGER REFERENCES users(id),
+		UNIQUE (user_id, name)
+	);
+	INSERT INTO tags_new (id, name) SELECT id, name FROM tags;
+	DROP TABLE tags;
+	ALTER TABLE tags_new RENAME TO tags;
+	`,
+	// 3: roles and api keys, the oldest user becomes admin
+	`
+	ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'member';
+	UPDATE users SET role = 'admin' WHERE id = (SELECT MIN(id) FROM users);
+	CREATE TABLE api_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		name TEXT NOT NULL,
+		role TEXT NOT NULL,
+		key_hash TEXT NOT NULL UNIQUE,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+	// 4: projects, each project has own tags namespace, project_id = 0 - personal tasks and tags of the user
+	`
+	CREATE TABLE projects (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		name TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (user_id, name)
+	);
+	ALTER TABLE tasks ADD COLUMN project_id INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX idx_tasks_project ON tasks(project_id);
+
+	CREATE TABLE tags_new (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		user_id INTEGER REFERENCES users(id),
+		project_id INTEGER NOT NULL DEFAULT 0,
+		UNIQUE (user_id, project_id, name)
+	);
+	INSERT INTO tags_new (id, name, user_id) SELECT id, name, user_id FROM tags;
+	DROP TABLE tags;
+	ALTER TABLE tags_new RENAME TO tags;
+	`,
+	// 5: assignees and shares, share grants task or project to the user, not granted entity id is 0
+	`
+	ALTER TABLE tasks ADD COLUMN assignee_id INTEGER NOT NULL DEFAULT 0;
+	CREATE INDEX idx_tasks_assignee ON tasks(assignee_id);
+	CREATE TABLE shares (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		task_id INTEGER NOT NULL DEFAULT 0,
+		project_id INTEGER NOT NULL DEFAULT 0,
+		permission TEXT NOT NULL,
+		UNIQUE (user_id, task_id, project_id)
+	);
+	CREATE INDEX idx_shares_task ON shares(task_id);
+	CREATE INDEX idx_shares_project ON shares(project_id);
+	`,
+	// 6: priority of tasks, 0 - P0 the most important, indexes for sorting by priority and due
+	`
+	ALTER TABLE tasks ADD COLUMN priority INTEGER NOT NULL DEFAULT 2;
+	CREATE INDEX idx_tasks_priority_due ON tasks(priority, due);
+	CREATE INDEX idx_tasks_due ON tasks(due);
+	`,
+	// 7: subtasks, done flag and checklist items of tasks, parent_id = 0 - top level task
+	`
+	ALTER TABLE tasks ADD COLUMN parent_id INTEGER NOT NULL DEFAULT 0;
+	ALTER TABLE tasks ADD COLUMN done BOOLEAN NOT NULL DEFAULT 0;
+	CREATE INDEX idx_tasks_parent ON tasks(parent_id);
+	CREATE TABLE checklist_items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL REFERENCES tasks(id),
+		text TEXT NOT NULL,
+		done BOOLEAN NOT NULL DEFAULT 0
+	);
+	CREATE INDEX idx_checklist_items_task ON checklist_items(task_id);
+	`,
+	// 8: dependencies between tasks, blocker task blocks blocked task until blocker is done
+	`
+	CREATE TABLE task_dependencies (
+		blocker_id INTEGER NOT NULL REFERENCES tasks(id),
+		blocked_id INTEGER NOT NULL REFERENCES tasks(id),
+		PRIMARY KEY (blocker_id, blocked_id)
+	);
+	CREATE INDEX idx_task_dependencies_blocked ON task_dependencies(blocked_id);
+	`,
+	// 9: user defined order of tasks, position is fractional, so moved task gets position between neighbours
+	`
+	ALTER TABLE tasks ADD COLUMN position REAL NOT NULL DEFAULT 0;
+	UPDATE tasks SET position = id;
+	CREATE INDEX idx_tasks_position ON tasks(position);
+	`,
+	// 10: comments of tasks
+	`
+	CREATE TABLE comments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL REFERENCES tasks(id),
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		text TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX idx_comments_task ON comments(task_id);
+	`,
+	// 11: attachments of tasks, content is in blob store, blob is shared by attachments with same content
+	`
+	CREATE TABLE blobs (
+		hash TEXT PRIMARY KEY,
+		size INTEGER NOT NULL
+	);
+	CREATE TABLE attachments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL REFERENCES tasks(id),
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		name TEXT NOT NULL,
+		mime_type TEXT NOT NULL,
+		hash TEXT NOT NULL REFERENCES blobs(hash),
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX idx_attachments_task ON attachments(task_id);
+	CREATE INDEX idx_attachments_hash ON attachments(hash);
+	`,
+	// 12: append-only audit log of changes, before and after - json snapshots of the entity,
+	// owner_id - owner of the task at the moment of change, so owner can read history of deleted task
+	`
+	CREATE TABLE audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		action TEXT NOT NULL,
+		entity TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		task_id INTEGER NOT NULL DEFAULT 0,
+		owner_id INTEGER NOT NULL DEFAULT 0,
+		before_data TEXT,
+		after_data TEXT,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	CREATE INDEX idx_audit_log_task ON audit_log(task_id);
+	CREATE INDEX idx_audit_log_entity ON audit_log(entity, entity_id);
+	CREATE INDEX idx_audit_log_created ON audit_log(created_at);
+	CREATE TRIGGER audit_log_no_update BEFORE UPDATE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END;
+	CREATE TRIGGER audit_log_no_delete BEFORE DELETE ON audit_log
+	BEGIN
+		SELECT RAISE(ABORT, 'audit log is append-only');
+	END;
+	`,
+	// 13: trash, deleted tasks and tags are kept with deleted_at until they are restored or purged
+	`
+	ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMP;
+	ALTER TABLE tags ADD COLUMN deleted_at TIMESTAMP;
+	CREATE INDEX idx_tasks_deleted ON tasks(deleted_at);
+	CREATE INDEX idx_tags_deleted ON tags(deleted_at);
+	`,
+	// 14: undoable operations, audit entries of the operation have its token
+	`
+	CREATE TABLE operations (
+		token TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id),
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		undone_at TIMESTAMP
+	);
+	CREATE INDEX idx_operations_user ON operations(user_id);
+	ALTER TABLE audit_log ADD COLUMN operation TEXT;
+	CREATE INDEX idx_audit_log_operation ON audit_log(operation);
+	`,
+	// 15: secret token of calendar feed, one per user
+	`
+	CREATE TABLE calendar_tokens (
+		user_id INTEGER PRIMARY KEY REFERENCES users(id),
+		token_hash TEXT NOT NULL UNIQUE,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+	);
+	`,
+	// 16: due in UTC, so due filters and order compare dues of different time zones.
+	// Due is stored as '2006-01-02 15:04:05.999999999-07:00', fraction of seconds is kept
+	`
+	UPDATE tasks SET due = datetime(due) || substr(due, 20, length(due) - 25) || '+00:00'
+	WHERE due LIKE '____-__-__ __:__:__%' AND substr(due, -6) <> '+00:00' AND datetime(due) IS NOT NULL;
+	`,
+	// 17: tasks and tags created before users belong to the oldest user, migration 2 left them without owner.
+	// Database without users keeps them, the first user gets them (see CreateUser).
+	// Tag the owner already has is not moved, ownerless copy is deleted, task_tags link tags by name
+	`
+	UPDATE tasks SET user_id = (SELECT MIN(id) FROM users) WHERE user_id IS NULL;
+	UPDATE OR IGNORE tags SET user_id = (SELECT MIN(id) FROM users) WHERE user_id IS NULL;
+	DELETE FROM tags WHERE user_id IS NULL AND EXISTS (SELECT 1 FROM users);
+	`,
+}
+
+// migrate applies not applied migrations, each in own transaction
+func (s *StoreSqlite) migrate() error {
+	const op = "sqlite.migrate"
+
+	var version int
+	err := s.DataBase.QueryRow(`PRAGMA user_version`).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("%v: %v", op, err.Error())
+	}
+
+	for ; version < len(migrations); version++ {
+		tx, err := s.DataBase.Begin()
+		if err != nil {
+			return fmt.Errorf("%v: %v", op, err.Error())
+		}
+
+		_, err = tx.Exec(migrations[version])
+		if err == nil {
+			// PRAGMA doesn't support placeholders
+			_, err = tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, version+1))
+		}
+		if err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("%v: migration %d: %v", op, version+1, err.Error())
+		}
+
+		if err = tx.Commit(); err != nil {
+			return fmt.Errorf("%v: migration %d: %v", op, version+1, err.Error())
+		}
+		s.Log.Info("Applied database migration", slog.Int("version", version+1))
+	}
+	return nil
+}
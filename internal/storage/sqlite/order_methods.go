@@ -0,0 +1,97 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+// minPositionGap gap between neighbours is widened if it is smaller, float64 can't split it precisely
+const minPositionGap = 1e-9
+
+func (s *StoreSqlite) MoveTask(userId int, id int, anchorId int, after bool) (*storage.Task, error) {
+	const op = "sqlite.MoveTask"
+
+	if id == anchorId {
+		return nil, ErrorSqliteNew(http.StatusBadRequest, "task can't be moved relative to itself")
+	}
+	if err := s.checkTaskAccess(userId, id, accessWrite); err != nil {
+		return nil, err
+	}
+	if err := s.checkTaskAccess(userId, anchorId, accessRead); err != nil {
+		return nil, err
+	}
+
+	// neighbours and new position are computed in one transaction, so concurrent moves don't get same position
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		before, err := s.taskById(tx, id)
+		if err != nil {
+			return err
+		}
+		position, err := movePosition(tx, userId, id, anchorId, after)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(`UPDATE tasks SET position = ? WHERE id = ?`, position, id); err != nil {
+			return err
+		}
+		moved, err := s.taskById(tx, id)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, userId, storage.AuditMove, storage.EntityTask, id, id, before, moved)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.GetTask(userId, id)
+}
+
+// movePosition returns position between anchor and its neighbour visible to the user, moved task is not neighbour.
+// Only tasks from the anchor to the end of order are shifted, if gap between neighbours is too small
+func movePosition(tx *sql.Tx, userId int, id int, anchorId int, after bool) (float64, error) {
+	// neighbour is the closest task before anchor, or after anchor if task is moved after it
+	compare, order, side := "<", "DESC", -1.0
+	if after {
+		compare, order, side = ">", "ASC", 1.0
+	}
+
+	var anchor float64
+	if err := tx.QueryRow(`SELECT position FROM tasks WHERE id = ?`, anchorId).Scan(&anchor); err != nil {
+		return 0, err
+	}
+
+	scope, scopeArgs := taskScope(userId, nil)
+	query := fmt.Sprintf(`SELECT t1.position FROM tasks t1 WHERE t1.position %s ? AND t1.id <> ? AND %s
+			ORDER BY t1.position %s LIMIT 1`, compare, scope, order)
+	var neighbour float64
+	err := tx.QueryRow(query, append([]interface{}{anchor, id}, scopeArgs...)...).Scan(&neighbour)
+	if errors.Is(err, sql.ErrNoRows) {
+		// anchor is the first or the last task
+		return anchor + side, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if (neighbour-anchor)*side < minPositionGap {
+		// tasks after the gap are shifted by 1, gap becomes wider than 1
+		shifted := anchor
+		if after {
+			shifted = neighbour
+		}
+		if _, err = tx.Exec(`UPDATE tasks SET position = position + 1 WHERE position >= ?`, shifted); err != nil {
+			return 0, err
+		}
+		if after {
+			neighbour++
+		} else {
+			anchor++
+		}
+	}
+	return (anchor + neighbour) / 2, nil
+}
@@ -0,0 +1,229 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"net/http"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+// checkProject returns 404 error if project doesn't belong to the user, projectId = 0 is personal project of each user
+func (s *StoreSqlite) checkProject(userId int, projectId int) error {
+	const op = "sqlite.checkProject"
+
+	if projectId == 0 {
+		return nil
+	}
+
+	var exists bool
+	err := s.db().QueryRow(`SELECT EXISTS (SELECT 1 FROM projects WHERE id = ? AND user_id = ?)`, projectId, userId).
+		Scan(&exists)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	if !exists {
+		return ErrorSqliteNew(http.StatusNotFound, "project not found")
+	}
+	return nil
+}
+
+// projectAccess returns owner of the project if the user owns it or project or its task is shared with him,
+// write - write share is required, 403 error if share is read only.
+// projectId = 0 is personal project of each user, its owner is the user
+func (s *StoreSqlite) projectAccess(userId int, projectId int, write bool) (int, error) {
+	const op = "sqlite.projectAccess"
+
+	if projectId == 0 {
+		return userId, nil
+	}
+
+	var ownerId int
+	var permission string
+	// assignee and users with share of the task of the project can read the project, so they can use its tags
+	err := s.db().QueryRow(`
+				SELECT p.user_id, COALESCE(
+					(SELECT permission FROM shares WHERE user_id = ? AND project_id = p.id),
+					(SELECT ? FROM tasks t1 WHERE t1.project_id = p.id AND t1.deleted_at IS NULL
+						AND (t1.assignee_id = ? OR t1.id IN (SELECT task_id FROM shares WHERE user_id = ?)) LIMIT 1),
+					'')
+				FROM projects p WHERE p.id = ?
+			`, userId, storage.PermissionRead, userId, userId, projectId).Scan(&ownerId, &permission)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return 0, err
+	}
+	switch {
+	case err == nil && ownerId == userId:
+		return ownerId, nil
+	case err != nil || permission == "":
+		return 0, ErrorSqliteNew(http.StatusNotFound, "project not found")
+	case write && permission != storage.PermissionWrite:
+		return 0, ErrorSqliteNew(http.StatusForbidden, "no write access to the project")
+	}
+	return ownerId, nil
+}
+
+// projectColumns columns of storage.Project, projects table alias must be p
+const projectColumns = `p.id, p.name, (SELECT name FROM users WHERE id = p.user_id), p.created_at`
+
+func (s *StoreSqlite) CreateProject(userId int, name string) (*storage.Project, error) {
+	const op = "sqlite.CreateProject"
+
+	var project storage.Project
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		err := tx.QueryRow(`INSERT INTO projects (user_id, name) VALUES (?, ?)
+				RETURNING id, name, (SELECT name FROM users WHERE id = user_id), created_at`,
+			userId, name).Scan(&project.Id, &project.Name, &project.Owner, &project.CreatedAt)
+		if err != nil {
+			var errSql sqlite3.Error
+			if errors.As(err, &errSql) && errSql.ExtendedCode == sqlite3.ErrConstraintUnique {
+				return ErrorSqliteNew(http.StatusConflict, "project already exists")
+			}
+			return err
+		}
+		return s.audit(tx, userId, storage.AuditCreate, storage.EntityProject, project.Id, 0, nil, project)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// projectById returns project by id without access check, q - database or transaction
+func projectById(q querier, id int) (*storage.Project, error) {
+	var project storage.Project
+	err := q.QueryRow(fmt.Sprintf(`SELECT %s FROM projects p WHERE p.id = ?`, projectColumns), id).
+		Scan(&project.Id, &project.Name, &project.Owner, &project.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrorSqliteNew(http.StatusNotFound, "project not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+func (s *StoreSqlite) GetProject(userId int, id int) (*storage.Project, error) {
+	const op = "sqlite.GetProject"
+
+	if _, err := s.projectAccess(userId, id, false); err != nil {
+		return nil, err
+	}
+
+	project, err := projectById(s.db(), id)
+	if err != nil {
+		if _, ok := err.(*ErrorSqlite); !ok {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		}
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *StoreSqlite) GetProjects(userId int) (*storage.Projects, error) {
+	const op = "sqlite.GetProjects"
+
+	rows, err := s.db().Query(fmt.Sprintf(`
+				SELECT %s FROM projects p
+				WHERE p.user_id = ? OR p.id IN (SELECT project_id FROM shares WHERE user_id = ? AND project_id <> 0)
+				ORDER BY p.id
+			`, projectColumns), userId, userId)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := storage.Projects{Projects: []storage.Project{}}
+	for rows.Next() {
+		var project storage.Project
+		if err := rows.Scan(&project.Id, &project.Name, &project.Owner, &project.CreatedAt); err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		projects.Projects = append(projects.Projects, project)
+	}
+	return &projects, nil
+}
+
+func (s *StoreSqlite) RenameProject(userId int, id int, name string) (*storage.Project, error) {
+	const op = "sqlite.RenameProject"
+
+	if err := s.checkProject(userId, id); err != nil {
+		return nil, err
+	}
+
+	var project *storage.Project
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		before, err := projectById(tx, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`UPDATE projects SET name = ? WHERE id = ?`, name, id)
+		if err != nil {
+			var errSql sqlite3.Error
+			if errors.As(err, &errSql) && errSql.ExtendedCode == sqlite3.ErrConstraintUnique {
+				return ErrorSqliteNew(http.StatusConflict, "project already exists")
+			}
+			return err
+		}
+		if project, err = projectById(tx, id); err != nil {
+			return err
+		}
+		return s.audit(tx, userId, storage.AuditUpdate, storage.EntityProject, id, 0, before, project)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return project, nil
+}
+
+func (s *StoreSqlite) DeleteProject(userId int, id int) error {
+	const op = "sqlite.DeleteProject"
+
+	if err := s.checkProject(userId, id); err != nil {
+		return err
+	}
+
+	return s.inTx(op, func(tx *sql.Tx) error {
+		project, err := projectById(tx, id)
+		if err != nil {
+			return err
+		}
+
+		// tasks and tags are deleted only through trash, so project is deleted when it has none of them
+		var tasks, tags, trashed int
+		err = tx.QueryRow(`
+				SELECT
+					(SELECT COUNT(*) FROM tasks WHERE project_id = ? AND deleted_at IS NULL),
+					(SELECT COUNT(*) FROM tags WHERE project_id = ? AND deleted_at IS NULL),
+					(SELECT COUNT(*) FROM tasks WHERE project_id = ? AND deleted_at IS NOT NULL) +
+					(SELECT COUNT(*) FROM tags WHERE project_id = ? AND deleted_at IS NOT NULL)
+			`, id, id, id, id).Scan(&tasks, &tags, &trashed)
+		if err != nil {
+			return err
+		}
+		if tasks > 0 || tags > 0 {
+			return ErrorSqliteNew(http.StatusConflict, fmt.Sprintf(
+				"project has %d task(s) and %d tag(s), move them to trash first", tasks, tags))
+		}
+		if trashed > 0 {
+			return ErrorSqliteNew(http.StatusConflict, fmt.Sprintf(
+				"project has %d task(s) and tag(s) in trash, empty trash first", trashed))
+		}
+
+		if err = s.audit(tx, userId, storage.AuditDelete, storage.EntityProject, id, 0, project, nil); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+				DELETE FROM shares WHERE project_id = ?;
+				DELETE FROM projects WHERE id = ?;
+			`, id, id)
+		return err
+	})
+}
@@ -0,0 +1,188 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+// shareTarget entity of the share, column - column of the entity id in shares table, table - table of the entity
+type shareTarget struct {
+	column string
+	table  string
+	// notFound message of error if entity doesn't belong to the user
+	notFound string
+}
+
+var (
+	taskShare    = shareTarget{column: "task_id", table: "tasks", notFound: "task not found"}
+	projectShare = shareTarget{column: "project_id", table: "projects", notFound: "project not found"}
+)
+
+// shareSnapshot share in audit log, one of TaskId and ProjectId is set
+type shareSnapshot struct {
+	TaskId     int    `json:"task_id,omitempty"`
+	ProjectId  int    `json:"project_id,omitempty"`
+	User       string `json:"user"`
+	Permission string `json:"permission"`
+}
+
+// auditShare records change of the share, empty permission - share doesn't exist
+func (s *StoreSqlite) auditShare(tx *sql.Tx, userId int, action string, target shareTarget, id int, granteeId int, name, before, after string) error {
+	snapshot := func(permission string) any {
+		if permission == "" {
+			return nil
+		}
+		share := shareSnapshot{User: name, Permission: permission}
+		if target == taskShare {
+			share.TaskId = id
+		} else {
+			share.ProjectId = id
+		}
+		return share
+	}
+
+	taskId := 0
+	if target == taskShare {
+		taskId = id
+	}
+	return s.audit(tx, userId, action, storage.EntityShare, granteeId, taskId, snapshot(before), snapshot(after))
+}
+
+// checkOwner returns 404 error if entity of the share doesn't belong to the user
+func (s *StoreSqlite) checkOwner(userId int, target shareTarget, id int) error {
+	const op = "sqlite.checkOwner"
+
+	var exists bool
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE id = ? AND user_id = ?)`, target.table)
+	if err := s.db().QueryRow(query, id, userId).Scan(&exists); err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	if !exists {
+		return ErrorSqliteNew(http.StatusNotFound, target.notFound)
+	}
+	return nil
+}
+
+// share grants permission on the entity to the user with name, permission of existing share is replaced
+func (s *StoreSqlite) share(userId int, target shareTarget, id int, name, permission string) error {
+	const op = "sqlite.share"
+
+	if err := s.checkOwner(userId, target, id); err != nil {
+		return err
+	}
+	granteeId, err := s.userIdByName(name)
+	if err != nil {
+		return err
+	}
+	if granteeId == userId {
+		return ErrorSqliteNew(http.StatusBadRequest, "can't share with yourself")
+	}
+
+	return s.inTx(op, func(tx *sql.Tx) error {
+		var before string
+		err := tx.QueryRow(fmt.Sprintf(`SELECT COALESCE((SELECT permission FROM shares WHERE user_id = ? AND %s = ?), '')`,
+			target.column), granteeId, id).Scan(&before)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(fmt.Sprintf(`
+					INSERT INTO shares (user_id, %s, permission) VALUES (?, ?, ?)
+					ON CONFLICT (user_id, task_id, project_id) DO UPDATE SET permission = excluded.permission
+				`, target.column), granteeId, id, permission)
+		if err != nil {
+			return err
+		}
+		action := storage.AuditCreate
+		if before != "" {
+			action = storage.AuditUpdate
+		}
+		return s.auditShare(tx, userId, action, target, id, granteeId, name, before, permission)
+	})
+}
+
+// unshare revokes share of the entity from the user with name
+func (s *StoreSqlite) unshare(userId int, target shareTarget, id int, name string) error {
+	const op = "sqlite.unshare"
+
+	if err := s.checkOwner(userId, target, id); err != nil {
+		return err
+	}
+
+	return s.inTx(op, func(tx *sql.Tx) error {
+		var granteeId int
+		var permission string
+		err := tx.QueryRow(fmt.Sprintf(`
+					SELECT user_id, permission FROM shares WHERE %s = ? AND user_id = (SELECT id FROM users WHERE name = ?)
+				`, target.column), id, name).Scan(&granteeId, &permission)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrorSqliteNew(http.StatusNotFound, "share not found")
+		}
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(fmt.Sprintf(`DELETE FROM shares WHERE %s = ? AND user_id = ?`, target.column), id, granteeId); err != nil {
+			return err
+		}
+		return s.auditShare(tx, userId, storage.AuditDelete, target, id, granteeId, name, permission, "")
+	})
+}
+
+// shares returns shares of the entity
+func (s *StoreSqlite) shares(userId int, target shareTarget, id int) (*storage.Shares, error) {
+	const op = "sqlite.shares"
+
+	if err := s.checkOwner(userId, target, id); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db().Query(fmt.Sprintf(`
+				SELECT u.name, sh.permission FROM shares sh JOIN users u ON u.id = sh.user_id
+				WHERE sh.%s = ? ORDER BY u.name
+			`, target.column), id)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := storage.Shares{Shares: []storage.Share{}}
+	for rows.Next() {
+		var share storage.Share
+		if err := rows.Scan(&share.User, &share.Permission); err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		shares.Shares = append(shares.Shares, share)
+	}
+	return &shares, nil
+}
+
+func (s *StoreSqlite) ShareTask(userId int, taskId int, name, permission string) error {
+	return s.share(userId, taskShare, taskId, name, permission)
+}
+
+func (s *StoreSqlite) UnshareTask(userId int, taskId int, name string) error {
+	return s.unshare(userId, taskShare, taskId, name)
+}
+
+func (s *StoreSqlite) GetTaskShares(userId int, taskId int) (*storage.Shares, error) {
+	return s.shares(userId, taskShare, taskId)
+}
+
+func (s *StoreSqlite) ShareProject(userId int, projectId int, name, permission string) error {
+	return s.share(userId, projectShare, projectId, name, permission)
+}
+
+func (s *StoreSqlite) UnshareProject(userId int, projectId int, name string) error {
+	return s.unshare(userId, projectShare, projectId, name)
+}
+
+func (s *StoreSqlite) GetProjectShares(userId int, projectId int) (*storage.Shares, error) {
+	return s.shares(userId, projectShare, projectId)
+}
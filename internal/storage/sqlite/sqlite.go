@@ -13,6 +13,10 @@ import (
 type StoreSqlite struct {
 	DataBase *sql.DB
 	Log      *slog.Logger
+	// operation token of undoable operation changes are recorded to, empty - changes are not undoable
+	operation string
+	// tx transaction of Transaction all methods run in, nil - each method runs in own transaction
+	tx *sql.Tx
 }
 
 // Connect connect to database
@@ -24,7 +28,143 @@ func (s *StoreSqlite) Connect(cfg *config.Config, log *slog.Logger) storage.Stor
 		log.Error(fmt.Sprintf("%v: %v", op, err.Error()))
 		os.Exit(1)
 	}
-	return &StoreSqlite{DataBase: db, Log: log}
+	store := &StoreSqlite{DataBase: db, Log: log}
+	if err = store.migrate(); err != nil {
+		log.Error(err.Error())
+		os.Exit(1)
+	}
+	return store
+}
+
+// Close closes database, it waits for started queries to finish
+func (s *StoreSqlite) Close() error {
+	const op = "sqlite.Close"
+
+	if err := s.DataBase.Close(); err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// Operation returns copy of the storage, which records changes with the token of the operation
+func (s *StoreSqlite) Operation(token string) storage.Storage {
+	store := *s
+	store.operation = token
+	return &store
+}
+
+func (s *StoreSqlite) Transaction(fn func(tx storage.Storage) error) error {
+	const op = "sqlite.Transaction"
+
+	if s.tx != nil {
+		return fn(s)
+	}
+
+	tx, err := s.DataBase.Begin()
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+
+	// panic of fn rolls back transaction too, otherwise transaction keeps lock of the database
+	defer rollbackOnPanic(tx)
+
+	// errors of fn are errors of the caller, errors of methods are logged by methods
+	store := *s
+	store.tx = tx
+	if err = fn(&store); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// db returns transaction of Transaction or database
+func (s *StoreSqlite) db() querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.DataBase
+}
+
+// querier common methods of *sql.DB and *sql.Tx, so helpers can read and write in or out of transaction
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// inTx runs fn in transaction, transaction is rolled back if fn returns error.
+// In transaction of Transaction fn runs in savepoint, so only changes of fn are rolled back.
+// Errors except ErrorSqlite are logged with op
+func (s *StoreSqlite) inTx(op string, fn func(tx *sql.Tx) error) error {
+	if s.tx != nil {
+		return s.inSavepoint(op, fn)
+	}
+
+	tx, err := s.DataBase.Begin()
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	defer rollbackOnPanic(tx)
+
+	if err = fn(tx); err != nil {
+		_ = tx.Rollback()
+		if _, ok := err.(*ErrorSqlite); !ok {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		}
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// inSavepoint runs fn in savepoint of transaction of Transaction, savepoint is rolled back if fn returns error
+func (s *StoreSqlite) inSavepoint(op string, fn func(tx *sql.Tx) error) error {
+	if _, err := s.tx.Exec(`SAVEPOINT method`); err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = s.tx.Exec(`ROLLBACK TO method; RELEASE method`)
+			panic(p)
+		}
+	}()
+
+	if err := fn(s.tx); err != nil {
+		// savepoint stays in transaction after rollback to it, so it is released too
+		_, _ = s.tx.Exec(`ROLLBACK TO method; RELEASE method`)
+		if _, ok := err.(*ErrorSqlite); !ok {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		}
+		return err
+	}
+
+	if _, err := s.tx.Exec(`RELEASE method`); err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	return nil
+}
+
+// rollbackOnPanic rolls back transaction and panics again if function deferring it panics
+func rollbackOnPanic(tx *sql.Tx) {
+	if p := recover(); p != nil {
+		_ = tx.Rollback()
+		panic(p)
+	}
 }
 
 type ErrorSqlite struct {
@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+	"web/internal/storage"
+)
+
+// testTag personal tag created for each test user
+const testTag = "work"
+
+// newTestStore returns storage with migrated database in temporary directory of the test
+func newTestStore(t *testing.T) *StoreSqlite {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	store := &StoreSqlite{DataBase: db, Log: slog.New(slog.NewTextHandler(io.Discard, nil))}
+	if err = store.migrate(); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// newTestUser creates user with personal testTag, returns id of the user
+func newTestUser(t *testing.T, s *StoreSqlite, name string) int {
+	t.Helper()
+
+	user, err := s.CreateUser(name, "hash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.CreateTag(user.Id, 0, testTag); err != nil {
+		t.Fatal(err)
+	}
+	return user.Id
+}
+
+// newTestTask creates personal task of the user with testTag, parentId 0 - top level task
+func newTestTask(t *testing.T, s storage.Storage, userId int, text string, parentId int) *storage.Task {
+	t.Helper()
+
+	due := time.Now().Add(24 * time.Hour)
+	task, err := s.CreateTask(userId, &storage.TaskCreate{Text: text, Tags: []string{testTag}, Due: &due, ParentId: parentId})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return task
+}
+
+// errorCode returns http status of storage error, 0 - nil error, -1 - error without status
+func errorCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	var errSql storage.SqlError
+	if errors.As(err, &errSql) {
+		return errSql.GetCode()
+	}
+	return -1
+}
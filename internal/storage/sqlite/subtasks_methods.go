@@ -0,0 +1,247 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+// checkParent returns error if parent task is not visible to the user, is in other project than projectId
+// or is the task itself or its subtask of any depth, taskId = 0 - new task, parentId = 0 - top level task
+func (s *StoreSqlite) checkParent(userId int, taskId int, parentId int, projectId int) error {
+	const op = "sqlite.checkParent"
+
+	if parentId == 0 {
+		return nil
+	}
+
+	scope, scopeArgs := taskScope(userId, nil)
+	var parentProjectId int
+	err := s.db().QueryRow(fmt.Sprintf(`SELECT t1.project_id FROM tasks t1 WHERE t1.id = ? AND %s`, scope),
+		append([]interface{}{parentId}, scopeArgs...)...).Scan(&parentProjectId)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrorSqliteNew(http.StatusNotFound, "parent task not found")
+	}
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	if parentProjectId != projectId {
+		return ErrorSqliteNew(http.StatusBadRequest, "parent task is in other project")
+	}
+	if taskId == 0 {
+		return nil
+	}
+
+	// ancestors of the parent include the task itself if the task becomes its own subtask
+	var cycle bool
+	err = s.db().QueryRow(`
+				WITH RECURSIVE ancestors(id) AS (
+					SELECT ?
+					UNION
+					SELECT t.parent_id FROM tasks t JOIN ancestors a ON t.id = a.id WHERE t.parent_id <> 0
+				)
+				SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = ?)
+			`, parentId, taskId).Scan(&cycle)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	if cycle {
+		return ErrorSqliteNew(http.StatusBadRequest, "task can't be subtask of itself or its subtasks")
+	}
+	return nil
+}
+
+func (s *StoreSqlite) GetTaskTree(userId int, id int) (*storage.TaskTree, error) {
+	const op = "sqlite.GetTaskTree"
+
+	root, err := s.GetTask(userId, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// subtasks of all levels, invisible subtask hides its subtasks too
+	scope, scopeArgs := taskScope(userId, nil)
+	query := fmt.Sprintf(`
+				WITH RECURSIVE tree(id) AS (
+					SELECT ?
+					UNION
+					SELECT t1.id FROM tasks t1 JOIN tree ON t1.parent_id = tree.id WHERE %s
+				)
+				SELECT %s FROM tasks t1 WHERE t1.id IN tree AND t1.id <> ? ORDER BY t1.id
+			`, scope, taskColumns)
+	rows, err := s.db().Query(query, append(append([]interface{}{id}, scopeArgs...), id)...)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	children := make(map[int][]storage.Task)
+	ids := []interface{}{id}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		children[task.ParentId] = append(children[task.ParentId], *task)
+		ids = append(ids, task.Id)
+	}
+
+	checklists, err := s.checklists(ids)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	return buildTree(*root, children, checklists), nil
+}
+
+// buildTree returns tree of the task, children - subtasks by parent id, checklists - checklist items by task id
+func buildTree(task storage.Task, children map[int][]storage.Task, checklists map[int][]storage.ChecklistItem) *storage.TaskTree {
+	tree := storage.TaskTree{
+		Task:      task,
+		Checklist: checklists[task.Id],
+		Subtasks:  []storage.TaskTree{},
+	}
+	if tree.Checklist == nil {
+		tree.Checklist = []storage.ChecklistItem{}
+	}
+	for _, child := range children[task.Id] {
+		tree.Subtasks = append(tree.Subtasks, *buildTree(child, children, checklists))
+	}
+	return &tree
+}
+
+// checklists returns checklist items of the tasks by task id
+func (s *StoreSqlite) checklists(taskIds []interface{}) (map[int][]storage.ChecklistItem, error) {
+	rows, err := s.db().Query(fmt.Sprintf(`
+				SELECT task_id, id, text, done FROM checklist_items WHERE task_id IN (%s) ORDER BY id
+			`, strings.Trim(strings.Repeat("?,", len(taskIds)), ",")), taskIds...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	checklists := make(map[int][]storage.ChecklistItem)
+	for rows.Next() {
+		var taskId int
+		var item storage.ChecklistItem
+		if err := rows.Scan(&taskId, &item.Id, &item.Text, &item.Done); err != nil {
+			return nil, err
+		}
+		checklists[taskId] = append(checklists[taskId], item)
+	}
+	return checklists, nil
+}
+
+func (s *StoreSqlite) GetChecklist(userId int, taskId int) (*storage.Checklist, error) {
+	const op = "sqlite.GetChecklist"
+
+	if err := s.checkTaskAccess(userId, taskId, accessRead); err != nil {
+		return nil, err
+	}
+
+	checklists, err := s.checklists([]interface{}{taskId})
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+
+	checklist := storage.Checklist{Items: checklists[taskId]}
+	if checklist.Items == nil {
+		checklist.Items = []storage.ChecklistItem{}
+	}
+	return &checklist, nil
+}
+
+func (s *StoreSqlite) CreateChecklistItem(userId int, taskId int, text string) (*storage.ChecklistItem, error) {
+	const op = "sqlite.CreateChecklistItem"
+
+	if err := s.checkTaskAccess(userId, taskId, accessWrite); err != nil {
+		return nil, err
+	}
+
+	var item storage.ChecklistItem
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		err := tx.QueryRow(`INSERT INTO checklist_items (task_id, text) VALUES (?, ?) RETURNING id, text, done`,
+			taskId, text).Scan(&item.Id, &item.Text, &item.Done)
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, userId, storage.AuditCreate, storage.EntityChecklistItem, item.Id, taskId, nil, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// checklistItem returns checklist item of the task, q - database or transaction
+func checklistItem(q querier, taskId int, id int) (*storage.ChecklistItem, error) {
+	var item storage.ChecklistItem
+	err := q.QueryRow(`SELECT id, text, done FROM checklist_items WHERE id = ? AND task_id = ?`, id, taskId).
+		Scan(&item.Id, &item.Text, &item.Done)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrorSqliteNew(http.StatusNotFound, "checklist item not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (s *StoreSqlite) UpdateChecklistItem(userId int, taskId int, id int, text *string, done *bool) (*storage.ChecklistItem, error) {
+	const op = "sqlite.UpdateChecklistItem"
+
+	if err := s.checkTaskAccess(userId, taskId, accessWrite); err != nil {
+		return nil, err
+	}
+
+	var item *storage.ChecklistItem
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		before, err := checklistItem(tx, taskId, id)
+		if err != nil {
+			return err
+		}
+		// nil fields keep current values
+		_, err = tx.Exec(`UPDATE checklist_items SET text = COALESCE(?, text), done = COALESCE(?, done) WHERE id = ?`,
+			text, done, id)
+		if err != nil {
+			return err
+		}
+		if item, err = checklistItem(tx, taskId, id); err != nil {
+			return err
+		}
+		return s.audit(tx, userId, storage.AuditUpdate, storage.EntityChecklistItem, id, taskId, before, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (s *StoreSqlite) DeleteChecklistItem(userId int, taskId int, id int) error {
+	const op = "sqlite.DeleteChecklistItem"
+
+	if err := s.checkTaskAccess(userId, taskId, accessWrite); err != nil {
+		return err
+	}
+
+	return s.inTx(op, func(tx *sql.Tx) error {
+		item, err := checklistItem(tx, taskId, id)
+		if err == nil {
+			err = s.audit(tx, userId, storage.AuditDelete, storage.EntityChecklistItem, id, taskId, item, nil)
+		}
+		if err == nil {
+			_, err = tx.Exec(`DELETE FROM checklist_items WHERE id = ?`, id)
+		}
+		return err
+	})
+}
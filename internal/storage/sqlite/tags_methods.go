@@ -2,22 +2,28 @@ package sqlite
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
-	"github.com/mattn/go-sqlite3"
 	"net/http"
 	"web/internal/storage"
 )
 
 // INFO: docs of this function in web/internal/storage/storage.go
 
-func (s *StoreSqlite) GetAllTags() (*storage.Tags, error) {
+func (s *StoreSqlite) GetAllTags(userId int, projectId int) (*storage.Tags, error) {
 	const op = "sqlite.GetAllTags"
 
-	rows, err := s.DataBase.Query(`SELECT id, name FROM tags`)
+	ownerId, err := s.projectAccess(userId, projectId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db().Query(`SELECT id, name FROM tags WHERE user_id = ? AND project_id = ? AND deleted_at IS NULL`, ownerId, projectId)
 	if err != nil {
 		s.Log.Error("%v: %v", op, err.Error())
 		return nil, err
 	}
+	defer rows.Close()
 
 	var allTags storage.Tags
 
@@ -39,14 +45,21 @@ func (s *StoreSqlite) GetAllTags() (*storage.Tags, error) {
 	return &allTags, nil
 }
 
-func (s *StoreSqlite) GetTag(name string) (*storage.Tag, error) {
+func (s *StoreSqlite) GetTag(userId int, projectId int, name string) (*storage.Tag, error) {
 	const op = "sqlite.GetTag"
 
-	rows, err := s.DataBase.Query(`SELECT id, name FROM tags WHERE name = ?`, name)
+	ownerId, err := s.projectAccess(userId, projectId, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db().Query(`SELECT id, name FROM tags WHERE name = ? AND user_id = ? AND project_id = ? AND deleted_at IS NULL`,
+		name, ownerId, projectId)
 	if err != nil {
 		s.Log.Error("%v: %v", op, err.Error())
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
 		var id int
@@ -61,42 +74,77 @@ func (s *StoreSqlite) GetTag(name string) (*storage.Tag, error) {
 	return nil, fmt.Errorf("no tags found")
 }
 
-func (s *StoreSqlite) CreateTag(name string) error {
+func (s *StoreSqlite) CreateTag(userId int, projectId int, name string) error {
 	const op = "sqlite.CreateTag"
 
-	_, err := s.DataBase.Exec(`INSERT INTO tags (name) VALUES (?)`, name)
+	// tags of the project belong to the owner of the project
+	ownerId, err := s.projectAccess(userId, projectId, true)
 	if err != nil {
-		if errSql := err.(sqlite3.Error); errSql.ExtendedCode == sqlite3.ErrConstraintUnique {
-			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
-			return ErrorSqliteNew(http.StatusConflict, "tag already exists")
-		}
-		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
 		return err
 	}
-	return nil
+
+	return s.inTx(op, func(tx *sql.Tx) error {
+		// trashed tag with same name is restored, not trashed tag is not updated, so no row is returned
+		tag := storage.Tag{Name: name, ProjectId: projectId}
+		err := tx.QueryRow(`
+					INSERT INTO tags (name, user_id, project_id) VALUES (?, ?, ?)
+					ON CONFLICT (user_id, project_id, name) DO UPDATE SET deleted_at = NULL WHERE tags.deleted_at IS NOT NULL
+					RETURNING id
+				`, name, ownerId, projectId).Scan(&tag.Id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrorSqliteNew(http.StatusConflict, "tag already exists")
+		}
+		if err != nil {
+			return err
+		}
+		return s.audit(tx, userId, storage.AuditCreate, storage.EntityTag, tag.Id, 0, nil, tag)
+	})
 }
 
-func (s *StoreSqlite) DeleteTag(name ...string) error {
+func (s *StoreSqlite) DeleteTag(userId int, projectId int, name ...string) error {
 	const op = "sqlite.DeleteTag"
 
-	var err error
-	var result sql.Result
+	ownerId, err := s.projectAccess(userId, projectId, true)
+	if err != nil {
+		return err
+	}
 
+	condition := `user_id = ? AND project_id = ? AND deleted_at IS NULL`
+	args := []interface{}{ownerId, projectId}
 	switch len(name) {
 	case 0:
-		result, err = s.DataBase.Exec(`DELETE FROM tags`)
 	case 1:
-		result, err = s.DataBase.Exec(`DELETE FROM tags WHERE name = ?`, name[0])
+		condition += ` AND name = ?`
+		args = append(args, name[0])
 	default:
 		return fmt.Errorf("database: delete: expect 0 or 1 args, got %d", len(name))
 	}
 
-	if err != nil {
-		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+	return s.inTx(op, func(tx *sql.Tx) error {
+		rows, err := tx.Query(fmt.Sprintf(`SELECT id, name FROM tags WHERE %s`, condition), args...)
+		if err != nil {
+			return err
+		}
+		var tags []storage.Tag
+		for rows.Next() {
+			tag := storage.Tag{ProjectId: projectId}
+			if err := rows.Scan(&tag.Id, &tag.Name); err != nil {
+				rows.Close()
+				return err
+			}
+			tags = append(tags, tag)
+		}
+		rows.Close()
+		if len(tags) == 0 {
+			return ErrorSqliteNew(http.StatusNotFound, "tag not found")
+		}
+
+		for _, tag := range tags {
+			if err := s.audit(tx, userId, storage.AuditDelete, storage.EntityTag, tag.Id, 0, tag, nil); err != nil {
+				return err
+			}
+		}
+		_, err = tx.Exec(fmt.Sprintf(`UPDATE tags SET deleted_at = CURRENT_TIMESTAMP WHERE %s`, condition), args...)
 		return err
-	}
-	if count, _ := result.RowsAffected(); count == 0 {
-		return ErrorSqliteNew(http.StatusNotFound, "task not found")
-	}
-	return nil
+	})
 }
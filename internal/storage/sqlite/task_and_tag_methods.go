@@ -9,13 +9,13 @@ import (
 
 // Methods for get tasks by tag
 
-func (s *StoreSqlite) GetTasksByTagFull(tagList []string) (*storage.Tasks, error) {
+func (s *StoreSqlite) GetTasksByTagFull(userId int, tagList []string, filter *storage.TaskFilter) (*storage.Tasks, error) {
 	const op = "sqlite.GetAllTasksByTag"
 
 	// create query and args for this query
-	query, args := buildQueryFull(tagList)
+	query, args := buildQueryFull(userId, tagList, filter)
 	// get rows from database
-	rows, err := s.DataBase.Query(query, args...)
+	rows, err := s.db().Query(query, args...)
 
 	if err != nil {
 		s.Log.Error("%v: %v", op, err.Error())
@@ -25,13 +25,13 @@ func (s *StoreSqlite) GetTasksByTagFull(tagList []string) (*storage.Tasks, error
 	return getTasksFromRows(rows)
 }
 
-func (s *StoreSqlite) GetTasksByTagShort(tagList []string) (*storage.Tasks, error) {
+func (s *StoreSqlite) GetTasksByTagShort(userId int, tagList []string, filter *storage.TaskFilter) (*storage.Tasks, error) {
 	const op = "sqlite.GetTaskByTag"
 
 	// create query and args for this query
-	query, args := buildQueryShort(tagList)
+	query, args := buildQueryShort(userId, tagList, filter)
 	// get rows from database
-	rows, err := s.DataBase.Query(query, args...)
+	rows, err := s.db().Query(query, args...)
 
 	if err != nil {
 		s.Log.Error("%v: %v", op, err.Error())
@@ -43,15 +43,16 @@ func (s *StoreSqlite) GetTasksByTagShort(tagList []string) (*storage.Tasks, erro
 }
 
 // buildQuery builds query and args for GetAllTasksByTag
-func buildQueryShort(tagList []string) (string, []interface{}) {
+func buildQueryShort(userId int, tagList []string, filter *storage.TaskFilter) (string, []interface{}) {
 	var query string
 	var args = make([]interface{}, len(tagList))
+	scope, scopeArgs := taskScope(userId, filter)
 
 	// Todo if len == 0
 	switch len(tagList) {
 	case 1:
-		query = `
-					SELECT t1.id, t1.text, t1.tags, t1.due
+		query = fmt.Sprintf(`
+					SELECT %s
 					FROM tasks t1
 					JOIN task_tags t2 ON t1.id = t2.task_id
 					WHERE tag_name = ?  AND NOT EXISTS (
@@ -59,16 +60,17 @@ func buildQueryShort(tagList []string) (string, []interface{}) {
 						FROM task_tags t3
 						WHERE t3.task_id = t1.id
 						AND t3.tag_name <> ?
-					)	
-				`
+					) AND %s
+				`, taskColumns, scope)
 		for n, v := range tagList {
 			args[n] = v
 		}
 		args = append(args, args...)
+		args = append(args, scopeArgs...)
 	default:
 		tagsString := strings.Trim(strings.Repeat("?,", len(tagList)), ",")
 		query = fmt.Sprintf(`
-					SELECT t1.id, t1.text, t1.tags, t1.due
+					SELECT %s
 					FROM tasks t1
 					JOIN task_tags t2 ON t1.id = t2.task_id
 					WHERE tag_name IN (%s)  AND NOT EXISTS (
@@ -76,63 +78,67 @@ func buildQueryShort(tagList []string) (string, []interface{}) {
 						FROM task_tags t3
 						WHERE t3.task_id = t1.id
 						AND t3.tag_name NOT IN (%s) 
-					)
-					GROUP BY t1.id, t1.text, t1.tags, t1.due
+					) AND %s
+					GROUP BY t1.id
 					HAVING COUNT(DISTINCT tag_name) = ?
-				`, tagsString, tagsString)
+				`, taskColumns, tagsString, tagsString, scope)
 
 		for n, v := range tagList {
 			args[n] = v
 		}
 
 		args = append(args, args...)
+		args = append(args, scopeArgs...)
 		args = append(args, len(tagList))
 	}
-	return query, args
+	return orderTasks(query, filter), args
 }
 
-func buildQueryFull(tagList []string) (string, []interface{}) {
+func buildQueryFull(userId int, tagList []string, filter *storage.TaskFilter) (string, []interface{}) {
 	var query string
 	var args = make([]interface{}, len(tagList))
+	scope, scopeArgs := taskScope(userId, filter)
 
 	switch len(tagList) {
 	case 1:
-		query = `
-					SELECT t1.id, t1.text, t1.tags, t1.due 
+		query = fmt.Sprintf(`
+					SELECT %s 
 					FROM tasks t1 
 				    JOIN task_tags t2 ON t1.id = t2.task_id 
-					WHERE tag_name = ?
-					`
+					WHERE tag_name = ? AND %s
+					`, taskColumns, scope)
 		for n, v := range tagList {
 			args[n] = v
 		}
+		args = append(args, scopeArgs...)
 	default:
 		tagString := strings.Trim(strings.Repeat("?,", len(tagList)), ",")
 		// create query with tags
 		query = fmt.Sprintf(`
-					SELECT t1.id, t1.text, t1.tags, t1.due
+					SELECT %s
 					FROM tasks t1
 					JOIN task_tags t2 ON t1.id = t2.task_id
-					WHERE tag_name IN (%s)
-					GROUP BY t1.id, t1.text, t1.tags, t1.due
+					WHERE tag_name IN (%s) AND %s
+					GROUP BY t1.id
 					HAVING COUNT(DISTINCT tag_name) = ?;
-				`, tagString)
+				`, taskColumns, tagString, scope)
 
 		for n, v := range tagList {
 			args[n] = v
 		}
+		args = append(args, scopeArgs...)
 		args = append(args, len(tagList))
 	}
-	return query, args
+	return orderTasks(query, filter), args
 }
 
 // Methods for get tasks by tag and due date
 
-func (s *StoreSqlite) GetTasksByDueAndTagFull(tags []string, dueDate *time.Time) (*storage.Tasks, error) {
+func (s *StoreSqlite) GetTasksByDueAndTagFull(userId int, tags []string, dueDate *time.Time, filter *storage.TaskFilter) (*storage.Tasks, error) {
 	const op = "sqlite.GetTasksByDueAndTag"
-	query, args := buildQueryTagDueFull(tags, dueDate)
+	query, args := buildQueryTagDueFull(userId, tags, dueDate, filter)
 
-	rows, err := s.DataBase.Query(query, args...)
+	rows, err := s.db().Query(query, args...)
 	if err != nil {
 		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
 		return nil, err
@@ -141,12 +147,12 @@ func (s *StoreSqlite) GetTasksByDueAndTagFull(tags []string, dueDate *time.Time)
 	return getTasksFromRows(rows)
 }
 
-func (s *StoreSqlite) GetTasksByDueAndTagShort(tagList []string, dueDate *time.Time) (*storage.Tasks, error) {
+func (s *StoreSqlite) GetTasksByDueAndTagShort(userId int, tagList []string, dueDate *time.Time, filter *storage.TaskFilter) (*storage.Tasks, error) {
 	const op = "sqlite.GetTaskByDueAndTag"
 
-	query, args := buildQueryTagDueShort(tagList, dueDate)
+	query, args := buildQueryTagDueShort(userId, tagList, dueDate, filter)
 
-	rows, err := s.DataBase.Query(query, args...)
+	rows, err := s.db().Query(query, args...)
 	if err != nil {
 		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
 		return nil, err
@@ -154,101 +160,109 @@ func (s *StoreSqlite) GetTasksByDueAndTagShort(tagList []string, dueDate *time.T
 	return getTasksFromRows(rows)
 }
 
-func buildQueryTagDueFull(tagList []string, dueDate *time.Time) (string, []interface{}) {
+func buildQueryTagDueFull(userId int, tagList []string, dueDate *time.Time, filter *storage.TaskFilter) (string, []interface{}) {
 	const op = "sqlite.buildQueryTagDueShort"
 
-	args := make([]interface{}, len(tagList), len(tagList)+2)
+	args := make([]interface{}, len(tagList), len(tagList)+3)
 	var query string
+	scope, scopeArgs := taskScope(userId, filter)
 
 	switch len(tagList) {
 	case 1:
 		query = fmt.Sprintf(`
-									SELECT t1.id, t1.text, t1.tags, t1.due
+									SELECT %s
 									FROM tasks t1
 									Join task_tags t2 On t2.task_id = t1.id
-									WHERE t2.tag_name = ? AND t1.due = ?;
-							`)
+									WHERE t2.tag_name = ? AND t1.due = ? AND %s;
+							`, taskColumns, scope)
 		args[0] = tagList[0]
-		args = append(args, dueDate)
+		args = append(args, dueDate.UTC())
+		args = append(args, scopeArgs...)
 	default:
 		tagsString := strings.Trim(strings.Repeat("?,", len(tagList)), ",")
 		query = fmt.Sprintf(`
-									SELECT t1.id, t1.text, t1.tags, t1.due
+									SELECT %s
 									FROM tasks t1
 									Join task_tags t2 On t2.task_id = t1.id
-									WHERE t2.tag_name IN (%s) AND t1.due = ?
+									WHERE t2.tag_name IN (%s) AND t1.due = ? AND %s
 									GROUP BY t1.id
 									HAVING COUNT(DISTINCT t2.tag_name) = ?;
-							`, tagsString)
+							`, taskColumns, tagsString, scope)
 		for k, v := range tagList {
 			args[k] = v
 		}
-		args = append(args, dueDate)
+		args = append(args, dueDate.UTC())
+		args = append(args, scopeArgs...)
 		args = append(args, len(tagList))
 	}
-	return query, args
+	return orderTasks(query, filter), args
 }
 
-func buildQueryTagDueShort(tagList []string, dueDate *time.Time) (string, []interface{}) {
+func buildQueryTagDueShort(userId int, tagList []string, dueDate *time.Time, filter *storage.TaskFilter) (string, []interface{}) {
 	const op = "sqlite.buildQueryTagDueShort"
 
-	args := make([]interface{}, len(tagList), len(tagList)+2)
+	args := make([]interface{}, len(tagList), len(tagList)+3)
 	var query string
+	scope, scopeArgs := taskScope(userId, filter)
 
 	switch len(tagList) {
 	case 1:
 		query = fmt.Sprintf(`
-									SELECT t1.id, t1.text, t1.tags, t1.due
+									SELECT %s
 									FROM tasks t1
 									JOIN task_tags t2 ON t1.id = t2.task_id
-									WHERE t2.tag_name = ? AND t1.due = ? AND NOT EXISTS (
+									WHERE t2.tag_name = ? AND t1.due = ? AND %s AND NOT EXISTS (
 									SELECT 1
 									FROM task_tags t3
 									WHERE t3.task_id = t1.id
 									AND t3.tag_name <> ?);
-							`)
+							`, taskColumns, scope)
 		args[0] = tagList[0]
-		args = append(args, dueDate)
+		args = append(args, dueDate.UTC())
+		args = append(args, scopeArgs...)
 		args = append(args, tagList[0])
 	default:
 		tagsString := strings.Trim(strings.Repeat("?,", len(tagList)), ",")
 		//goland:noinspection ALL
 		query = fmt.Sprintf(`
-								    SELECT t1.id, t1.text, t1.tags, t1.due
+								    SELECT %s
 									FROM tasks t1
 									JOIN task_tags t2 ON t1.id = t2.task_id
-									WHERE t2.tag_name IN (%s) AND t1.due = ? AND NOT EXISTS (
+									WHERE t2.tag_name IN (%s) AND t1.due = ? AND %s AND NOT EXISTS (
 										SELECT 1
 										FROM task_tags t3
 										WHERE t3.task_id = t1.id
 										AND t3.tag_name NOT IN (%s))
 									GROUP BY t1.id
 									HAVING COUNT(DISTINCT t2.tag_name) = ?;
-							`, tagsString, tagsString)
+							`, taskColumns, tagsString, scope, tagsString)
 		for k, v := range tagList {
 			args[k] = v
 		}
-		args = append(args, dueDate)
+		args = append(args, dueDate.UTC())
+		args = append(args, scopeArgs...)
 		args = append(args, args[:len(tagList)]...)
 		args = append(args, len(tagList))
 	}
-	return query, args
+	return orderTasks(query, filter), args
 }
 
-func (s *StoreSqlite) GetTasksByTag(tagList []string) (*storage.Tasks, error) {
+func (s *StoreSqlite) GetTasksByTag(userId int, tagList []string, filter *storage.TaskFilter) (*storage.Tasks, error) {
 	tagString := strings.Trim(strings.Repeat("?,", len(tagList)), ",")
+	scope, scopeArgs := taskScope(userId, filter)
 	query := fmt.Sprintf(`
-										SELECT DISTINCT t1.id,t1.text,t1.tags,t1.due
+										SELECT DISTINCT %s
 										FROM tasks t1
 										JOIN task_tags t2
 										ON t1.id = t2.task_id
-										WHERE t2.tag_name IN (%s);
-									`, tagString)
+										WHERE t2.tag_name IN (%s) AND %s;
+									`, taskColumns, tagString, scope)
 	args := make([]interface{}, len(tagList))
 	for k, v := range tagList {
 		args[k] = v
 	}
-	rows, err := s.DataBase.Query(query, args...)
+	args = append(args, scopeArgs...)
+	rows, err := s.db().Query(orderTasks(query, filter), args...)
 	if err != nil {
 		return nil, err
 	}
@@ -256,22 +270,24 @@ func (s *StoreSqlite) GetTasksByTag(tagList []string) (*storage.Tasks, error) {
 	return getTasksFromRows(rows)
 }
 
-func (s *StoreSqlite) GetTasksByTagAndDue(tagList []string, dueDate *time.Time) (*storage.Tasks, error) {
+func (s *StoreSqlite) GetTasksByTagAndDue(userId int, tagList []string, dueDate *time.Time, filter *storage.TaskFilter) (*storage.Tasks, error) {
 	tagString := strings.Trim(strings.Repeat("?,", len(tagList)), ",")
+	scope, scopeArgs := taskScope(userId, filter)
 
 	query := fmt.Sprintf(`
-								SELECT DISTINCT t1.id,t1.text,t1.tags,t1.due
+								SELECT DISTINCT %s
 								FROM tasks t1
 								JOIN task_tags t2
 								ON t1.id = t2.task_id
-								WHERE t2.tag_name IN (%s) AND t1.due = ?;
-								`, tagString)
+								WHERE t2.tag_name IN (%s) AND t1.due = ? AND %s;
+								`, taskColumns, tagString, scope)
 	args := make([]interface{}, len(tagList))
 	for k, v := range tagList {
 		args[k] = v
 	}
-	args = append(args, dueDate)
-	rows, err := s.DataBase.Query(query, args...)
+	args = append(args, dueDate.UTC())
+	args = append(args, scopeArgs...)
+	rows, err := s.db().Query(orderTasks(query, filter), args...)
 	if err != nil {
 		return nil, err
 	}
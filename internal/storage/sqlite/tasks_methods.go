@@ -2,8 +2,10 @@ package sqlite
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 	"web/internal/storage"
@@ -11,20 +13,174 @@ import (
 
 // INFO: docs of this function in web/internal/storage/storage.go
 
+// taskColumns columns of storage.Task in the order expected by scanTask, tasks table alias must be t1
+const taskColumns = `t1.id, t1.text, t1.tags, t1.due, t1.project_id,
+	COALESCE((SELECT name FROM users WHERE id = t1.assignee_id), ''), t1.priority, t1.parent_id, t1.done,
+	t1.position, (SELECT COUNT(*) FROM comments c WHERE c.task_id = t1.id), t1.deleted_at, ` + blockedTask
+
+// blockedTask condition of the task (table alias t1) with not done blockers, trashed blockers don't block
+const blockedTask = `EXISTS (SELECT 1 FROM task_dependencies d JOIN tasks b ON b.id = d.blocker_id
+	WHERE d.blocked_id = t1.id AND b.done = 0 AND b.deleted_at IS NULL)`
+
+// access levels of the task, each level is narrower than previous one
+const (
+	// accessRead owner, assignee and users with any share of the task or its project
+	accessRead = iota
+	// accessWrite owner, assignee and users with write share
+	accessWrite
+	// accessDelete owner and users with write share
+	accessDelete
+)
+
+// taskAccess returns condition of the task (table alias t1) access of the user with level, and args of condition
+func taskAccess(userId int, level int) (string, []interface{}) {
+	shared := `EXISTS (SELECT 1 FROM shares sh WHERE sh.user_id = ?
+		AND (sh.task_id = t1.id OR sh.project_id <> 0 AND sh.project_id = t1.project_id)`
+	if level != accessRead {
+		shared += fmt.Sprintf(` AND sh.permission = '%s'`, storage.PermissionWrite)
+	}
+	shared += ")"
+
+	if level == accessDelete {
+		return "(t1.user_id = ? OR " + shared + ")", []interface{}{userId, userId}
+	}
+	return "(t1.user_id = ? OR t1.assignee_id = ? OR " + shared + ")", []interface{}{userId, userId, userId}
+}
+
+// taskScope returns condition limiting tasks (table alias t1) to the not trashed tasks visible to the user matching filter,
+// and args of condition
+func taskScope(userId int, filter *storage.TaskFilter) (string, []interface{}) {
+	condition, args := taskAccess(userId, accessRead)
+	conditions := []string{condition, "t1.deleted_at IS NULL"}
+
+	if filter != nil && filter.ProjectId != 0 {
+		conditions = append(conditions, "t1.project_id = ?")
+		args = append(args, filter.ProjectId)
+	}
+	if filter != nil && filter.Assignee != "" {
+		conditions = append(conditions, "t1.assignee_id = (SELECT id FROM users WHERE name = ?)")
+		args = append(args, filter.Assignee)
+	}
+	if filter != nil && len(filter.Priorities) != 0 {
+		conditions = append(conditions, fmt.Sprintf("t1.priority IN (%s)",
+			strings.Trim(strings.Repeat("?,", len(filter.Priorities)), ",")))
+		for _, priority := range filter.Priorities {
+			args = append(args, priority)
+		}
+	}
+	if filter != nil && filter.Ready {
+		conditions = append(conditions, "t1.done = 0 AND NOT "+blockedTask)
+	}
+	// due is stored in UTC (see dueUTC), so it is compared with bounds in UTC
+	if filter != nil && filter.DueFrom != nil {
+		conditions = append(conditions, "t1.due >= ?")
+		args = append(args, filter.DueFrom.UTC())
+	}
+	if filter != nil && filter.DueTo != nil {
+		conditions = append(conditions, "t1.due < ?")
+		args = append(args, filter.DueTo.UTC())
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// dueUTC returns due in UTC, nil if due is not set. Due is stored in UTC, so stored dues of different
+// time zones are compared and ordered as strings
+func dueUTC(due *time.Time) interface{} {
+	if due == nil {
+		return nil
+	}
+	return due.UTC()
+}
+
+// orderTasks appends order of filter to the tasks query (table alias t1),
+// order by priority uses idx_tasks_priority_due, order by position uses idx_tasks_position
+func orderTasks(query string, filter *storage.TaskFilter) string {
+	if filter == nil {
+		return query
+	}
+
+	var order string
+	switch filter.Sort {
+	case storage.SortPriority:
+		order = "t1.priority, t1.due, t1.id"
+	case storage.SortDue:
+		order = "t1.due, t1.id"
+	case storage.SortPosition:
+		order = "t1.position, t1.id"
+	default:
+		return query
+	}
+	return strings.TrimRight(strings.TrimSpace(query), ";") + " ORDER BY " + order
+}
+
+// checkTaskAccess returns 404 error if task is trashed or not visible to the user and 403 error if user has no access with level
+func (s *StoreSqlite) checkTaskAccess(userId int, id interface{}, level int) error {
+	const op = "sqlite.checkTaskAccess"
+
+	visible, visibleArgs := taskAccess(userId, accessRead)
+	allowed, allowedArgs := taskAccess(userId, level)
+	query := fmt.Sprintf(`SELECT %s FROM tasks t1 WHERE t1.id = ? AND t1.deleted_at IS NULL AND %s`, allowed, visible)
+
+	var ok bool
+	err := s.db().QueryRow(query, append(append(allowedArgs, id), visibleArgs...)...).Scan(&ok)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrorSqliteNew(http.StatusNotFound, "task not found")
+	}
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	if !ok {
+		return ErrorSqliteNew(http.StatusForbidden, "no write access to the task")
+	}
+	return nil
+}
+
+func (s *StoreSqlite) CheckTaskWriteAccess(userId int, taskId int) error {
+	return s.checkTaskAccess(userId, taskId, accessWrite)
+}
+
+// scanTask scans row selected with taskColumns
+func scanTask(rows *sql.Rows) (*storage.Task, error) {
+	var id, projectId int
+	var text string
+	var tags string
+	var due string
+	var assignee string
+	var priority, parentId int
+	var done, blocked bool
+	var position float64
+	var commentCount int
+	var deletedAt sql.NullString
+	err := rows.Scan(&id, &text, &tags, &due, &projectId, &assignee, &priority, &parentId, &done, &position,
+		&commentCount, &deletedAt, &blocked)
+	if err != nil {
+		return nil, err
+	}
+	task := storage.NewTask(id, text, tags, due)
+	task.ProjectId = projectId
+	task.Assignee = assignee
+	task.Priority = storage.PriorityName(priority)
+	task.ParentId = parentId
+	task.Done = done
+	task.Blocked = blocked
+	task.Position = position
+	task.CommentCount = commentCount
+	task.DeletedAt = deletedAt.String
+	return task, nil
+}
+
 func getTasksFromRows(rows *sql.Rows) (*storage.Tasks, error) {
 	const op = "sqlite.getAllTasksFromRows"
+	defer rows.Close()
 	var allTasks storage.Tasks
 
 	for rows.Next() {
-		var id int
-		var text string
-		var tags string
-		var due string
-		err := rows.Scan(&id, &text, &tags, &due)
+		task, err := scanTask(rows)
 		if err != nil {
 			return nil, err
 		}
-		allTasks.Tasks = append(allTasks.Tasks, *storage.NewTask(id, text, tags, due))
+		allTasks.Tasks = append(allTasks.Tasks, *task)
 	}
 
 	if len(allTasks.Tasks) == 0 {
@@ -34,37 +190,177 @@ func getTasksFromRows(rows *sql.Rows) (*storage.Tasks, error) {
 	return &allTasks, nil
 }
 
-func (s *StoreSqlite) CreateTask(text string, tags []string, dueDate *time.Time) error {
+func (s *StoreSqlite) CreateTask(userId int, task *storage.TaskCreate) (*storage.Task, error) {
 	const op = "sqlite.CreateTask"
-	// add task
-	res, err := s.DataBase.Exec(`INSERT INTO tasks(text, tags, due) VALUES (?, ?, ?)`, text, strings.Join(tags, "; "), dueDate)
+
+	ownerId, err := s.projectAccess(userId, task.ProjectId, true)
 	if err != nil {
-		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
-		return err
+		return nil, err
+	}
+
+	assigneeId := 0
+	if task.Assignee != "" {
+		if assigneeId, err = s.userIdByName(task.Assignee); err != nil {
+			return nil, err
+		}
+	}
+	if err = s.checkParent(userId, 0, task.ParentId, task.ProjectId); err != nil {
+		return nil, err
+	}
+
+	var created *storage.Task
+	err = s.inTx(op, func(tx *sql.Tx) error {
+		// add task
+		res, err := tx.Exec(`
+					INSERT INTO tasks(text, tags, due, user_id, project_id, assignee_id, priority, parent_id, position)
+					VALUES (?, ?, ?, ?, ?, ?, ?, ?, (SELECT COALESCE(MAX(position), 0) + 1 FROM tasks))
+				`, task.Text, strings.Join(task.Tags, "; "), dueUTC(task.Due), ownerId, task.ProjectId, assigneeId, task.Priority,
+			task.ParentId)
+		if err != nil {
+			return err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		// add tags to task
+		if err = insertTaskTags(tx, id, task.Tags); err != nil {
+			return err
+		}
+
+		if created, err = s.taskById(tx, int(id)); err != nil {
+			return err
+		}
+		return s.audit(tx, userId, storage.AuditCreate, storage.EntityTask, created.Id, created.Id, nil, created)
+	})
+	if err != nil {
+		return nil, err
 	}
+	return created, nil
+}
 
-	id, err := res.LastInsertId()
+func (s *StoreSqlite) UpdateTask(userId int, id int, update *storage.TaskUpdate) (*storage.Task, error) {
+	const op = "sqlite.UpdateTask"
+
+	if err := s.checkTaskAccess(userId, id, accessWrite); err != nil {
+		return nil, err
+	}
+
+	var columns []string
+	var args []interface{}
+	if update.Text != nil {
+		columns = append(columns, "text = ?")
+		args = append(args, *update.Text)
+	}
+	if update.Tags != nil {
+		columns = append(columns, "tags = ?")
+		args = append(args, strings.Join(update.Tags, "; "))
+	}
+	if update.Due != nil {
+		columns = append(columns, "due = ?")
+		args = append(args, dueUTC(update.Due))
+	}
+	if update.Priority != nil {
+		columns = append(columns, "priority = ?")
+		args = append(args, *update.Priority)
+	}
+	if update.Done != nil {
+		columns = append(columns, "done = ?")
+		args = append(args, *update.Done)
+	}
+	if update.ParentId != nil {
+		var projectId int
+		err := s.db().QueryRow(`SELECT project_id FROM tasks WHERE id = ?`, id).Scan(&projectId)
+		if err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		if err = s.checkParent(userId, id, *update.ParentId, projectId); err != nil {
+			return nil, err
+		}
+		columns = append(columns, "parent_id = ?")
+		args = append(args, *update.ParentId)
+	}
+	if update.Assignee != nil {
+		assigneeId := 0
+		if *update.Assignee != "" {
+			var err error
+			if assigneeId, err = s.userIdByName(*update.Assignee); err != nil {
+				return nil, err
+			}
+		}
+		columns = append(columns, "assignee_id = ?")
+		args = append(args, assigneeId)
+	}
+	if len(columns) == 0 {
+		return s.GetTask(userId, id)
+	}
+
+	// user may lose access to the task by changing assignee, so task is returned without access check
+	var after *storage.Task
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		before, err := s.taskById(tx, id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(fmt.Sprintf(`UPDATE tasks SET %s WHERE id = ?`, strings.Join(columns, ", ")), append(args, id)...)
+		if err != nil {
+			return err
+		}
+		if update.Tags != nil {
+			if _, err = tx.Exec(`DELETE FROM task_tags WHERE task_id = ?`, id); err != nil {
+				return err
+			}
+			if err = insertTaskTags(tx, int64(id), update.Tags); err != nil {
+				return err
+			}
+		}
+		if after, err = s.taskById(tx, id); err != nil {
+			return err
+		}
+		return s.audit(tx, userId, storage.AuditUpdate, storage.EntityTask, id, id, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// taskById returns task by id without access check, q - database or transaction
+func (s *StoreSqlite) taskById(q querier, id int) (*storage.Task, error) {
+	const op = "sqlite.taskById"
+
+	rows, err := q.Query(fmt.Sprintf(`SELECT %s FROM tasks t1 WHERE t1.id = ?`, taskColumns), id)
 	if err != nil {
 		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
-		return err
+		return nil, err
+	}
+
+	tasks, err := getTasksFromRows(rows)
+	if err != nil {
+		return nil, err
 	}
+	return &tasks.Tasks[0], nil
+}
 
-	// add tags to task
+// insertTaskTags links tags to the task
+func insertTaskTags(tx *sql.Tx, taskId int64, tags []string) error {
 	for _, tagName := range tags {
-		_, err := s.DataBase.Exec(`INSERT INTO task_tags (task_id, tag_name) VALUES (?, ?)`, id, tagName)
+		_, err := tx.Exec(`INSERT INTO task_tags (task_id, tag_name) VALUES (?, ?)`, taskId, tagName)
 		if err != nil {
-			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
 			return err
 		}
 	}
 	return nil
 }
 
-func (s *StoreSqlite) GetTasksByDueDate(due *time.Time) (*storage.Tasks, error) {
+func (s *StoreSqlite) GetTasksByDueDate(userId int, due *time.Time, filter *storage.TaskFilter) (*storage.Tasks, error) {
 	const op = "sqlite.GetTasksByDueDate"
 
-	query := fmt.Sprintf(`SELECT id, text, tags, due FROM tasks WHERE due = ?`)
-	rows, err := s.DataBase.Query(query, due)
+	scope, scopeArgs := taskScope(userId, filter)
+	query := fmt.Sprintf(`SELECT %s FROM tasks t1 WHERE t1.due = ? AND %s`, taskColumns, scope)
+	rows, err := s.db().Query(orderTasks(query, filter), append([]interface{}{due.UTC()}, scopeArgs...)...)
 	if err != nil {
 		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
 		return nil, err
@@ -75,10 +371,12 @@ func (s *StoreSqlite) GetTasksByDueDate(due *time.Time) (*storage.Tasks, error)
 }
 
 // GetAllTasks returns all tasks
-func (s *StoreSqlite) GetAllTasks() (*storage.Tasks, error) {
+func (s *StoreSqlite) GetAllTasks(userId int, filter *storage.TaskFilter) (*storage.Tasks, error) {
 	const op = "sqlite.GetAllTasks"
 
-	rows, err := s.DataBase.Query(`SELECT id, text, tags, due FROM tasks`)
+	scope, scopeArgs := taskScope(userId, filter)
+	query := fmt.Sprintf(`SELECT %s FROM tasks t1 WHERE %s`, taskColumns, scope)
+	rows, err := s.db().Query(orderTasks(query, filter), scopeArgs...)
 	if err != nil {
 		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
 		return nil, err
@@ -87,53 +385,73 @@ func (s *StoreSqlite) GetAllTasks() (*storage.Tasks, error) {
 	return getTasksFromRows(rows)
 }
 
-func (s *StoreSqlite) GetTask(id int) (*storage.Task, error) {
+func (s *StoreSqlite) GetTask(userId int, id int) (*storage.Task, error) {
 	const op = "sqlite.GetTask"
 
-	rows, err := s.DataBase.Query(`SELECT id, text, tags, due FROM tasks WHERE id = ?`, id)
+	scope, scopeArgs := taskScope(userId, nil)
+	query := fmt.Sprintf(`SELECT %s FROM tasks t1 WHERE t1.id = ? AND %s`, taskColumns, scope)
+	rows, err := s.db().Query(query, append([]interface{}{id}, scopeArgs...)...)
 	if err != nil {
 		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
 		return nil, err
 	}
+	defer rows.Close()
 
 	for rows.Next() {
-		var id int
-		var text string
-		var tags string
-		var due string
-		err := rows.Scan(&id, &text, &tags, &due)
+		task, err := scanTask(rows)
 		if err != nil {
 			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
 			return nil, err
 		}
-		task := storage.NewTask(id, text, tags, due)
 		return task, nil
 	}
 	return nil, ErrorSqliteNew(http.StatusNotFound, "task not found")
 }
 
-func (s *StoreSqlite) DeleteTask(args ...string) error {
+func (s *StoreSqlite) DeleteTask(userId int, args ...string) error {
 	const op = "sqlite.Delete"
-	var err error
-	var result sql.Result
 
 	switch len(args) {
 	case 1:
-		// delete task by id
-		result, err = s.DataBase.Exec(`DELETE FROM tasks WHERE id = ?; DELETE FROM task_tags WHERE task_id = ?;`, args[0], args[0])
+		if err := s.checkTaskAccess(userId, args[0], accessDelete); err != nil {
+			return err
+		}
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return ErrorSqliteNew(http.StatusBadRequest, "invalid task id")
+		}
+		return s.inTx(op, func(tx *sql.Tx) error {
+			return s.trashTask(tx, userId, id)
+		})
 	case 0:
-		// delete all tasks
-		result, err = s.DataBase.Exec(`DELETE FROM tasks; DELETE FROM task_tags;`)
+		return s.inTx(op, func(tx *sql.Tx) error {
+			rows, err := tx.Query(fmt.Sprintf(`SELECT %s FROM tasks t1 WHERE t1.user_id = ? AND t1.deleted_at IS NULL`,
+				taskColumns), userId)
+			if err != nil {
+				return err
+			}
+			tasks, err := getTasksFromRows(rows)
+			if err != nil {
+				return ErrorSqliteNew(http.StatusNotFound, "task not found")
+			}
+			// subtasks of other users become top level tasks
+			err = s.reparentTasks(tx, userId, `t1.user_id <> ? AND t1.parent_id IN
+					(SELECT id FROM tasks WHERE user_id = ? AND deleted_at IS NULL)`, []interface{}{userId, userId}, 0)
+			if err != nil {
+				return err
+			}
+			for i := range tasks.Tasks {
+				task := &tasks.Tasks[i]
+				if err = s.audit(tx, userId, storage.AuditDelete, storage.EntityTask, task.Id, task.Id, task, nil); err != nil {
+					return err
+				}
+			}
+			// move all own tasks of the user to trash
+			_, err = tx.Exec(`UPDATE tasks SET deleted_at = CURRENT_TIMESTAMP WHERE user_id = ? AND deleted_at IS NULL`,
+				userId)
+			return err
+		})
 	default:
 		return fmt.Errorf("database: delete: expect 0 or 1 args, got %d", len(args))
 	}
-	if err != nil {
-		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
-		return err
-	}
-	if count, _ := result.RowsAffected(); count == 0 {
-		return ErrorSqliteNew(http.StatusNotFound, "task not found")
-	}
-
-	return nil
 }
@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"web/internal/storage"
+)
+
+func TestTasksAreScopedToUser(t *testing.T) {
+	s := newTestStore(t)
+	aliceId := newTestUser(t, s, "alice")
+	bobId := newTestUser(t, s, "bob")
+	carolId := newTestUser(t, s, "carol")
+
+	alice := newTestTask(t, s, aliceId, "alice", 0)
+	bob := newTestTask(t, s, bobId, "bob", 0)
+	shared := newTestTask(t, s, aliceId, "shared", 0)
+	if err := s.ShareTask(aliceId, shared.Id, "carol", storage.PermissionRead); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		userId   int
+		task     *storage.Task
+		wantCode int
+	}{
+		{name: "own task", userId: aliceId, task: alice, wantCode: 0},
+		{name: "task of other user", userId: bobId, task: alice, wantCode: http.StatusNotFound},
+		{name: "other user's own task", userId: bobId, task: bob, wantCode: 0},
+		{name: "task shared with user", userId: carolId, task: shared, wantCode: 0},
+		{name: "task not shared with user", userId: carolId, task: alice, wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task, err := s.GetTask(tt.userId, tt.task.Id)
+			if got := errorCode(err); got != tt.wantCode {
+				t.Fatalf("GetTask() error = %v, want status %d", err, tt.wantCode)
+			}
+			if err == nil && task.Text != tt.task.Text {
+				t.Errorf("text = %q, want %q", task.Text, tt.task.Text)
+			}
+		})
+	}
+
+	all := []struct {
+		name      string
+		userId    int
+		wantTexts []string
+	}{
+		{name: "owner", userId: aliceId, wantTexts: []string{"alice", "shared"}},
+		{name: "other user", userId: bobId, wantTexts: []string{"bob"}},
+		{name: "user with share", userId: carolId, wantTexts: []string{"shared"}},
+	}
+	for _, tt := range all {
+		t.Run("all tasks of "+tt.name, func(t *testing.T) {
+			tasks, err := s.GetAllTasks(tt.userId, &storage.TaskFilter{Sort: storage.SortPosition})
+			if err != nil {
+				t.Fatal(err)
+			}
+			texts := []string{}
+			for _, task := range tasks.Tasks {
+				texts = append(texts, task.Text)
+			}
+			if !reflect.DeepEqual(texts, tt.wantTexts) {
+				t.Errorf("tasks = %v, want %v", texts, tt.wantTexts)
+			}
+		})
+	}
+}
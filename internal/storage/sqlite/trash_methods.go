@@ -0,0 +1,297 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+// purgeChunk max number of ids in one purge statement, sqlite limits number of query args
+const purgeChunk = 500
+
+// purgeStatements delete tasks with ids from %s list with all their parts, tasks are deleted last,
+// subtasks of other tasks become top level tasks
+var purgeStatements = []string{
+	`UPDATE tasks SET parent_id = 0 WHERE parent_id IN (%s)`,
+	`DELETE FROM checklist_items WHERE task_id IN (%s)`,
+	`DELETE FROM comments WHERE task_id IN (%s)`,
+	`DELETE FROM attachments WHERE task_id IN (%s)`,
+	`DELETE FROM task_dependencies WHERE blocker_id IN (%[1]s) OR blocked_id IN (%[1]s)`,
+	`DELETE FROM task_tags WHERE task_id IN (%s)`,
+	`DELETE FROM shares WHERE task_id <> 0 AND task_id IN (%s)`,
+	`DELETE FROM tasks WHERE id IN (%s)`,
+}
+
+// purgeTasks deletes tasks by ids forever with all their parts
+func purgeTasks(q querier, ids []int) error {
+	for start := 0; start < len(ids); start += purgeChunk {
+		chunk := ids[start:min(start+purgeChunk, len(ids))]
+		list := strings.Trim(strings.Repeat("?,", len(chunk)), ",")
+		args := make([]interface{}, len(chunk))
+		for i, id := range chunk {
+			args[i] = id
+		}
+
+		for _, statement := range purgeStatements {
+			statement = fmt.Sprintf(statement, list)
+			// each list of the statement needs own args
+			var statementArgs []interface{}
+			for i := 0; i < strings.Count(statement, list); i++ {
+				statementArgs = append(statementArgs, args...)
+			}
+			if _, err := q.Exec(statement, statementArgs...); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// trashedTasks condition of trashed tasks (table alias t1) which user can restore, and args of condition
+func trashedTasks(userId int) (string, []interface{}) {
+	access, args := taskAccess(userId, accessDelete)
+	return "t1.deleted_at IS NOT NULL AND " + access, args
+}
+
+// trashedTags condition of trashed tags of own projects and projects with write share of the user, and args of condition
+func trashedTags(userId int) (string, []interface{}) {
+	return `deleted_at IS NOT NULL AND (user_id = ? OR project_id IN
+		(SELECT project_id FROM shares WHERE user_id = ? AND project_id <> 0 AND permission = ?))`,
+		[]interface{}{userId, userId, storage.PermissionWrite}
+}
+
+// tagsWhere returns tags selected by condition, q - database or transaction
+func tagsWhere(q querier, condition string, args ...interface{}) ([]storage.Tag, error) {
+	rows, err := q.Query(fmt.Sprintf(`SELECT id, name, project_id, deleted_at FROM tags WHERE %s ORDER BY id`,
+		condition), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []storage.Tag{}
+	for rows.Next() {
+		var tag storage.Tag
+		var deletedAt sql.NullString
+		if err := rows.Scan(&tag.Id, &tag.Name, &tag.ProjectId, &deletedAt); err != nil {
+			return nil, err
+		}
+		tag.DeletedAt = deletedAt.String
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
+}
+
+// tasksWhere returns tasks (table alias t1) selected by condition, q - database or transaction
+func tasksWhere(q querier, condition string, args ...interface{}) ([]storage.Task, error) {
+	rows, err := q.Query(fmt.Sprintf(`SELECT %s FROM tasks t1 WHERE %s ORDER BY t1.id`, taskColumns, condition),
+		args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tasks := []storage.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, *task)
+	}
+	return tasks, rows.Err()
+}
+
+// trashTask moves task to trash, its subtasks are moved to the parent of the task.
+// Subtasks are audited before the task, so undo restores the task before its subtasks
+func (s *StoreSqlite) trashTask(tx *sql.Tx, userId int, id int) error {
+	task, err := s.taskById(tx, id)
+	if err != nil {
+		return err
+	}
+	if err = s.reparentTasks(tx, userId, "t1.parent_id = ?", []interface{}{id}, task.ParentId); err != nil {
+		return err
+	}
+	if err = s.audit(tx, userId, storage.AuditDelete, storage.EntityTask, id, id, task, nil); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE tasks SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?`, id)
+	return err
+}
+
+// reparentTasks moves tasks (table alias t1) selected by condition to the parent and audits their change
+func (s *StoreSqlite) reparentTasks(tx *sql.Tx, userId int, condition string, args []interface{}, parentId int) error {
+	tasks, err := tasksWhere(tx, condition, args...)
+	if err != nil {
+		return err
+	}
+	for i := range tasks {
+		before := &tasks[i]
+		if _, err = tx.Exec(`UPDATE tasks SET parent_id = ? WHERE id = ?`, parentId, before.Id); err != nil {
+			return err
+		}
+		after, err := s.taskById(tx, before.Id)
+		if err != nil {
+			return err
+		}
+		if err = s.audit(tx, userId, storage.AuditUpdate, storage.EntityTask, before.Id, before.Id, before, after); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *StoreSqlite) GetTrash(userId int) (*storage.Trash, error) {
+	const op = "sqlite.GetTrash"
+
+	condition, args := trashedTasks(userId)
+	tasks, err := tasksWhere(s.db(), condition, args...)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+
+	condition, args = trashedTags(userId)
+	tags, err := tagsWhere(s.db(), condition, args...)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	return &storage.Trash{Tasks: tasks, Tags: tags}, nil
+}
+
+func (s *StoreSqlite) RestoreTask(userId int, id int) (*storage.Task, error) {
+	const op = "sqlite.RestoreTask"
+
+	var task *storage.Task
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		condition, args := trashedTasks(userId)
+		trashed, err := tasksWhere(tx, "t1.id = ? AND "+condition, append([]interface{}{id}, args...)...)
+		if err != nil {
+			return err
+		}
+		if len(trashed) == 0 {
+			return ErrorSqliteNew(http.StatusNotFound, "task not found in trash")
+		}
+
+		// parent may be trashed or purged after the task was trashed
+		_, err = tx.Exec(`
+					UPDATE tasks SET deleted_at = NULL,
+						parent_id = CASE WHEN parent_id IN (SELECT id FROM tasks WHERE deleted_at IS NULL) THEN parent_id ELSE 0 END
+					WHERE id = ?
+				`, id)
+		if err != nil {
+			return err
+		}
+		if task, err = s.taskById(tx, id); err != nil {
+			return err
+		}
+		return s.audit(tx, userId, storage.AuditRestore, storage.EntityTask, id, id, trashed[0], task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+func (s *StoreSqlite) RestoreTag(userId int, id int) (*storage.Tag, error) {
+	const op = "sqlite.RestoreTag"
+
+	var tag storage.Tag
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		condition, args := trashedTags(userId)
+		trashed, err := tagsWhere(tx, "id = ? AND "+condition, append([]interface{}{id}, args...)...)
+		if err != nil {
+			return err
+		}
+		if len(trashed) == 0 {
+			return ErrorSqliteNew(http.StatusNotFound, "tag not found in trash")
+		}
+
+		if _, err = tx.Exec(`UPDATE tags SET deleted_at = NULL WHERE id = ?`, id); err != nil {
+			return err
+		}
+		tag = trashed[0]
+		tag.DeletedAt = ""
+		return s.audit(tx, userId, storage.AuditRestore, storage.EntityTag, id, 0, trashed[0], tag)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (s *StoreSqlite) EmptyTrash(userId int) error {
+	const op = "sqlite.EmptyTrash"
+
+	tasksCondition, tasksArgs := trashedTasks(userId)
+	tagsCondition, tagsArgs := trashedTags(userId)
+	return s.inTx(op, func(tx *sql.Tx) error {
+		_, err := s.purge(tx, userId, tasksCondition, tasksArgs, tagsCondition, tagsArgs)
+		return err
+	})
+}
+
+func (s *StoreSqlite) PurgeTrash(before time.Time) (int, error) {
+	const op = "sqlite.PurgeTrash"
+
+	// deleted_at is CURRENT_TIMESTAMP in UTC
+	threshold := before.UTC().Format(auditTimeFormat)
+	var count int
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		var err error
+		count, err = s.purge(tx, 0, "t1.deleted_at < ?", []interface{}{threshold},
+			"deleted_at < ?", []interface{}{threshold})
+		return err
+	})
+	return count, err
+}
+
+// purge deletes tasks and tags selected by conditions forever and audits them, userId = 0 - purged by server.
+// Returns number of deleted tasks and tags
+func (s *StoreSqlite) purge(tx *sql.Tx, userId int, tasksCondition string, tasksArgs []interface{},
+	tagsCondition string, tagsArgs []interface{}) (int, error) {
+	tasks, err := tasksWhere(tx, tasksCondition, tasksArgs...)
+	if err != nil {
+		return 0, err
+	}
+	tags, err := tagsWhere(tx, tagsCondition, tagsArgs...)
+	if err != nil {
+		return 0, err
+	}
+	if len(tasks) == 0 && len(tags) == 0 {
+		return 0, nil
+	}
+
+	for i := range tasks {
+		task := &tasks[i]
+		if err = s.audit(tx, userId, storage.AuditPurge, storage.EntityTask, task.Id, task.Id, task, nil); err != nil {
+			return 0, err
+		}
+	}
+	for _, tag := range tags {
+		if err = s.audit(tx, userId, storage.AuditPurge, storage.EntityTag, tag.Id, 0, tag, nil); err != nil {
+			return 0, err
+		}
+	}
+
+	// tasks are deleted by ids, because shares which give access to them are deleted too
+	ids := make([]int, len(tasks))
+	for i, task := range tasks {
+		ids[i] = task.Id
+	}
+	if err = purgeTasks(tx, ids); err != nil {
+		return 0, err
+	}
+	for _, tag := range tags {
+		if _, err = tx.Exec(`DELETE FROM tags WHERE id = ?`, tag.Id); err != nil {
+			return 0, err
+		}
+	}
+	return len(tasks) + len(tags), nil
+}
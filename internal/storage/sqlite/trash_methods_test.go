@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestRestoreSubtask(t *testing.T) {
+	tests := []struct {
+		name string
+		// parent what happens to parent after subtask is trashed: active, trashed or purged
+		parent string
+		// deleteAll subtask and parent are trashed together by delete of all tasks, so subtask keeps its parent
+		deleteAll    bool
+		wantParentId bool
+	}{
+		{name: "parent is active", parent: "active", wantParentId: true},
+		{name: "parent is trashed", parent: "trashed", wantParentId: false},
+		{name: "parent is purged", parent: "purged", wantParentId: false},
+		{name: "parent is trashed with subtask", parent: "trashed", deleteAll: true, wantParentId: false},
+		{name: "parent is purged after subtask is trashed with it", parent: "purged", deleteAll: true, wantParentId: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			userId := newTestUser(t, s, "alice")
+			parent := newTestTask(t, s, userId, "parent", 0)
+			subtask := newTestTask(t, s, userId, "subtask", parent.Id)
+
+			if tt.deleteAll {
+				if err := s.DeleteTask(userId); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				if err := s.DeleteTask(userId, strconv.Itoa(subtask.Id)); err != nil {
+					t.Fatal(err)
+				}
+				if tt.parent != "active" {
+					if err := s.DeleteTask(userId, strconv.Itoa(parent.Id)); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if tt.parent == "purged" {
+				// only parent is trashed before purge threshold
+				trashed := time.Now().Add(-2 * time.Hour).UTC().Format(auditTimeFormat)
+				if _, err := s.DataBase.Exec(`UPDATE tasks SET deleted_at = ? WHERE id = ?`, trashed, parent.Id); err != nil {
+					t.Fatal(err)
+				}
+				count, err := s.PurgeTrash(time.Now().Add(-time.Hour))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if count != 1 {
+					t.Fatalf("PurgeTrash() = %d, want 1", count)
+				}
+			}
+
+			restored, err := s.RestoreTask(userId, subtask.Id)
+			if err != nil {
+				t.Fatalf("RestoreTask() error = %v", err)
+			}
+			wantParentId := 0
+			if tt.wantParentId {
+				wantParentId = parent.Id
+			}
+			if restored.ParentId != wantParentId {
+				t.Errorf("parent id = %d, want %d", restored.ParentId, wantParentId)
+			}
+			if _, err = s.GetTask(userId, subtask.Id); err != nil {
+				t.Errorf("GetTask() of restored subtask error = %v", err)
+			}
+			if _, err = s.RestoreTask(userId, subtask.Id); errorCode(err) != http.StatusNotFound {
+				t.Errorf("second RestoreTask() error = %v, want status %d", err, http.StatusNotFound)
+			}
+		})
+	}
+}
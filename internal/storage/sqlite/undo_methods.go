@@ -0,0 +1,197 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+func (s *StoreSqlite) Undo(userId int, token string, window time.Duration) (*storage.AuditLog, error) {
+	const op = "sqlite.Undo"
+
+	var undone *storage.AuditLog
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		// created_at is CURRENT_TIMESTAMP in UTC
+		query := `SELECT token, created_at >= ?, undone_at IS NOT NULL FROM operations WHERE user_id = ?`
+		args := []interface{}{time.Now().Add(-window).UTC().Format(auditTimeFormat), userId}
+		if token != "" {
+			query += ` AND token = ?`
+			args = append(args, token)
+		} else {
+			query += ` AND undone_at IS NULL ORDER BY rowid DESC LIMIT 1`
+		}
+		var inWindow, isUndone bool
+		err := tx.QueryRow(query, args...).Scan(&token, &inWindow, &isUndone)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrorSqliteNew(http.StatusNotFound, "nothing to undo")
+		}
+		if err != nil {
+			return err
+		}
+		if isUndone {
+			return ErrorSqliteNew(http.StatusConflict, "operation is already undone")
+		}
+		if !inWindow {
+			return ErrorSqliteNew(http.StatusConflict, "undo window of the operation is over")
+		}
+
+		undone, err = s.auditLog(tx, op, fmt.Sprintf(`SELECT %s FROM audit_log a WHERE a.operation = ? ORDER BY a.id`,
+			auditColumns), token)
+		if err != nil {
+			return err
+		}
+		if err = checkNotChanged(tx, token, undone.Entries); err != nil {
+			return err
+		}
+
+		// trashed tasks are restored first, so restored subtasks find their restored parents
+		restored := make(map[int]*storage.Task)
+		for _, entry := range undone.Entries {
+			if entry.Entity != storage.EntityTask || entry.Action != storage.AuditDelete {
+				continue
+			}
+			if restored[entry.EntityId], err = s.taskById(tx, entry.EntityId); err != nil {
+				return err
+			}
+			if _, err = tx.Exec(`UPDATE tasks SET deleted_at = NULL WHERE id = ?`, entry.EntityId); err != nil {
+				return err
+			}
+		}
+
+		// changes are reversed from the newest one
+		for i := len(undone.Entries) - 1; i >= 0; i-- {
+			if err = s.undoEntry(tx, userId, &undone.Entries[i], restored); err != nil {
+				return err
+			}
+		}
+
+		_, err = tx.Exec(`UPDATE operations SET undone_at = CURRENT_TIMESTAMP WHERE token = ?`, token)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return undone, nil
+}
+
+// checkNotChanged returns 409 error if entity of any entry of the operation was changed after the operation
+func checkNotChanged(tx *sql.Tx, token string, entries []storage.AuditEntry) error {
+	for _, entry := range entries {
+		var changed bool
+		err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM audit_log WHERE entity = ? AND entity_id = ? AND id > ?
+				AND (operation IS NULL OR operation <> ?))`,
+			entry.Entity, entry.EntityId, entry.Id, token).Scan(&changed)
+		if err != nil {
+			return err
+		}
+		if changed {
+			return ErrorSqliteNew(http.StatusConflict,
+				fmt.Sprintf("%s %d was changed after the operation", entry.Entity, entry.EntityId))
+		}
+	}
+	return nil
+}
+
+// undoEntry reverses change of the entry and audits it, restored - trashed tasks of the operation already restored.
+// Access is not checked, author of the operation was allowed to make it, e.g. to move subtasks of other users
+func (s *StoreSqlite) undoEntry(tx *sql.Tx, userId int, entry *storage.AuditEntry, restored map[int]*storage.Task) error {
+	switch entry.Entity {
+	case storage.EntityTask:
+		tasks, err := tasksWhere(tx, "t1.id = ?", entry.EntityId)
+		if err != nil {
+			return err
+		}
+		if len(tasks) == 0 {
+			return ErrorSqliteNew(http.StatusNotFound, "task not found")
+		}
+
+		switch entry.Action {
+		case storage.AuditCreate, storage.AuditRestore:
+			return s.trashTask(tx, userId, entry.EntityId)
+		case storage.AuditUpdate, storage.AuditMove, storage.AuditDelete:
+			var task storage.Task
+			if err = json.Unmarshal(entry.Before, &task); err != nil {
+				return err
+			}
+			if err = restoreTaskFields(tx, &task); err != nil {
+				return err
+			}
+			after, err := s.taskById(tx, task.Id)
+			if err != nil {
+				return err
+			}
+			if entry.Action == storage.AuditDelete {
+				return s.audit(tx, userId, storage.AuditRestore, storage.EntityTask, task.Id, task.Id,
+					restored[task.Id], after)
+			}
+			return s.audit(tx, userId, storage.AuditUpdate, storage.EntityTask, task.Id, task.Id, &tasks[0], after)
+		}
+	case storage.EntityTag:
+		// created tag has no snapshot before change
+		snapshot := entry.Before
+		if snapshot == nil {
+			snapshot = entry.After
+		}
+		var tag storage.Tag
+		if err := json.Unmarshal(snapshot, &tag); err != nil {
+			return err
+		}
+		tags, err := tagsWhere(tx, "id = ?", tag.Id)
+		if err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return ErrorSqliteNew(http.StatusNotFound, "tag not found")
+		}
+
+		switch entry.Action {
+		case storage.AuditCreate, storage.AuditRestore:
+			if _, err = tx.Exec(`UPDATE tags SET deleted_at = CURRENT_TIMESTAMP WHERE id = ?`, tag.Id); err != nil {
+				return err
+			}
+			return s.audit(tx, userId, storage.AuditDelete, storage.EntityTag, tag.Id, 0, tags[0], nil)
+		case storage.AuditDelete:
+			if _, err = tx.Exec(`UPDATE tags SET deleted_at = NULL WHERE id = ?`, tag.Id); err != nil {
+				return err
+			}
+			after := tags[0]
+			after.DeletedAt = ""
+			return s.audit(tx, userId, storage.AuditRestore, storage.EntityTag, tag.Id, 0, tags[0], after)
+		}
+	}
+	return ErrorSqliteNew(http.StatusConflict, fmt.Sprintf("%s %s can't be undone", entry.Entity, entry.Action))
+}
+
+// restoreTaskFields writes fields of the task snapshot to the task, task becomes top level task if its parent is not active
+func restoreTaskFields(tx *sql.Tx, task *storage.Task) error {
+	due, err := time.Parse(time.RFC3339Nano, task.Due)
+	if err != nil {
+		return err
+	}
+	priority, err := storage.ParsePriority(task.Priority)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+				UPDATE tasks SET text = ?, tags = ?, due = ?, priority = ?, done = ?, position = ?,
+					assignee_id = COALESCE((SELECT id FROM users WHERE name = ?), 0),
+					parent_id = CASE WHEN ? IN (SELECT id FROM tasks WHERE deleted_at IS NULL) THEN ? ELSE 0 END
+				WHERE id = ?
+			`, task.Text, strings.Join(task.Tags, "; "), due.UTC(), priority, task.Done, task.Position,
+		task.Assignee, task.ParentId, task.ParentId, task.Id)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM task_tags WHERE task_id = ?`, task.Id); err != nil {
+		return err
+	}
+	return insertTaskTags(tx, int64(task.Id), task.Tags)
+}
@@ -0,0 +1,91 @@
+package sqlite
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"web/internal/storage"
+)
+
+func TestUndo(t *testing.T) {
+	const window = 15 * time.Minute
+
+	tests := []struct {
+		name string
+		// age of the operation when it is undone
+		age time.Duration
+		// editAfter task is changed by other request after the operation
+		editAfter bool
+		wantCode  int
+		wantText  string
+	}{
+		{name: "inside window", age: 0, wantCode: 0, wantText: "before"},
+		{name: "just inside window", age: window - time.Minute, wantCode: 0, wantText: "before"},
+		{name: "outside window", age: window + time.Minute, wantCode: http.StatusConflict, wantText: "after"},
+		{name: "after later edit", age: 0, editAfter: true, wantCode: http.StatusConflict, wantText: "later"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			userId := newTestUser(t, s, "alice")
+			task := newTestTask(t, s, userId, "before", 0)
+
+			text := "after"
+			if _, err := s.Operation("token").UpdateTask(userId, task.Id, &storage.TaskUpdate{Text: &text}); err != nil {
+				t.Fatal(err)
+			}
+			created := time.Now().Add(-tt.age).UTC().Format(auditTimeFormat)
+			if _, err := s.DataBase.Exec(`UPDATE operations SET created_at = ? WHERE token = ?`, created, "token"); err != nil {
+				t.Fatal(err)
+			}
+			if tt.editAfter {
+				later := "later"
+				if _, err := s.UpdateTask(userId, task.Id, &storage.TaskUpdate{Text: &later}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			_, err := s.Undo(userId, "token", window)
+			if got := errorCode(err); got != tt.wantCode {
+				t.Fatalf("Undo() error = %v, want status %d", err, tt.wantCode)
+			}
+			got, err := s.GetTask(userId, task.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Text != tt.wantText {
+				t.Errorf("text = %q, want %q", got.Text, tt.wantText)
+			}
+		})
+	}
+}
+
+func TestUndoNewest(t *testing.T) {
+	s := newTestStore(t)
+	userId := newTestUser(t, s, "alice")
+	otherId := newTestUser(t, s, "bob")
+
+	first := newTestTask(t, s.Operation("first"), userId, "first", 0)
+	second := newTestTask(t, s.Operation("second"), userId, "second", 0)
+
+	// operation of other user is never undone
+	if _, err := s.Undo(otherId, "second", time.Hour); errorCode(err) != http.StatusNotFound {
+		t.Fatalf("Undo() of other user error = %v, want status %d", err, http.StatusNotFound)
+	}
+
+	// empty token undoes the newest not undone operation
+	for _, want := range []*storage.Task{second, first} {
+		if _, err := s.Undo(userId, "", time.Hour); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.GetTask(userId, want.Id); errorCode(err) != http.StatusNotFound {
+			t.Errorf("task %q after undo error = %v, want status %d", want.Text, err, http.StatusNotFound)
+		}
+	}
+	if _, err := s.Undo(userId, "", time.Hour); errorCode(err) != http.StatusNotFound {
+		t.Errorf("Undo() without operations error = %v, want status %d", err, http.StatusNotFound)
+	}
+	if _, err := s.Undo(userId, "first", time.Hour); errorCode(err) != http.StatusConflict {
+		t.Errorf("Undo() of undone operation error = %v, want status %d", err, http.StatusConflict)
+	}
+}
@@ -0,0 +1,298 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/mattn/go-sqlite3"
+	"net/http"
+	"time"
+	"web/internal/storage"
+)
+
+// INFO: docs of this function in web/internal/storage/storage.go
+
+func (s *StoreSqlite) CreateUser(name, passwordHash string) (*storage.User, error) {
+	const op = "sqlite.CreateUser"
+
+	var user *storage.User
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		var first bool
+		if err := tx.QueryRow(`SELECT NOT EXISTS (SELECT 1 FROM users)`).Scan(&first); err != nil {
+			return err
+		}
+		role := storage.RoleMember
+		if first {
+			role = storage.RoleAdmin
+		}
+
+		var id int
+		var createdAt string
+		err := tx.QueryRow(`INSERT INTO users (name, password_hash, role) VALUES (?, ?, ?) RETURNING id, created_at`,
+			name, passwordHash, role).Scan(&id, &createdAt)
+		if err != nil {
+			var errSql sqlite3.Error
+			if errors.As(err, &errSql) && errSql.ExtendedCode == sqlite3.ErrConstraintUnique {
+				return ErrorSqliteNew(http.StatusConflict, "user already exists")
+			}
+			return err
+		}
+
+		// tasks and tags created before users belong to the first user
+		if first {
+			_, err = tx.Exec(`
+					UPDATE tasks SET user_id = ? WHERE user_id IS NULL;
+					UPDATE tags SET user_id = ? WHERE user_id IS NULL;
+				`, id, id)
+			if err != nil {
+				return err
+			}
+		}
+		user = storage.NewUser(id, name, role, createdAt)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *StoreSqlite) SetUserRole(name, role string) (*storage.User, error) {
+	const op = "sqlite.SetUserRole"
+
+	var user *storage.User
+	err := s.inTx(op, func(tx *sql.Tx) error {
+		var id int
+		var current, createdAt string
+		err := tx.QueryRow(`SELECT id, role, created_at FROM users WHERE name = ?`, name).Scan(&id, &current, &createdAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrorSqliteNew(http.StatusNotFound, "user not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		// somebody must keep users:manage permission
+		if current == storage.RoleAdmin && role != storage.RoleAdmin {
+			var admins int
+			if err = tx.QueryRow(`SELECT COUNT(*) FROM users WHERE role = ?`, storage.RoleAdmin).Scan(&admins); err != nil {
+				return err
+			}
+			if admins == 1 {
+				return ErrorSqliteNew(http.StatusConflict, "user is the only admin")
+			}
+		}
+
+		if _, err = tx.Exec(`UPDATE users SET role = ? WHERE id = ?`, role, id); err != nil {
+			return err
+		}
+		user = storage.NewUser(id, name, role, createdAt)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *StoreSqlite) GetUserCredentials(name string) (*storage.User, string, error) {
+	const op = "sqlite.GetUserCredentials"
+
+	var id int
+	var role, createdAt, passwordHash string
+	err := s.db().QueryRow(`SELECT id, role, created_at, password_hash FROM users WHERE name = ?`, name).
+		Scan(&id, &role, &createdAt, &passwordHash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, "", ErrorSqliteNew(http.StatusNotFound, "user not found")
+	}
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, "", err
+	}
+	return storage.NewUser(id, name, role, createdAt), passwordHash, nil
+}
+
+func (s *StoreSqlite) CreateSession(userId int, tokenHash string, expiresAt time.Time) error {
+	const op = "sqlite.CreateSession"
+
+	_, err := s.db().Exec(`INSERT INTO sessions (token_hash, user_id, expires_at) VALUES (?, ?, ?)`,
+		tokenHash, userId, expiresAt.UTC())
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *StoreSqlite) GetUserBySession(tokenHash string) (*storage.User, error) {
+	const op = "sqlite.GetUserBySession"
+
+	var id int
+	var name, role, createdAt string
+	err := s.db().QueryRow(`
+				SELECT u.id, u.name, u.role, u.created_at
+				FROM sessions s
+				JOIN users u ON u.id = s.user_id
+				WHERE s.token_hash = ? AND s.expires_at > ?
+			`, tokenHash, time.Now().UTC()).Scan(&id, &name, &role, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrorSqliteNew(http.StatusUnauthorized, "session not found or expired")
+	}
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	return storage.NewUser(id, name, role, createdAt), nil
+}
+
+func (s *StoreSqlite) DeleteSession(tokenHash string) error {
+	const op = "sqlite.DeleteSession"
+
+	result, err := s.db().Exec(`DELETE FROM sessions WHERE token_hash = ?`, tokenHash)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return ErrorSqliteNew(http.StatusNotFound, "session not found")
+	}
+	return nil
+}
+
+func (s *StoreSqlite) CreateApiKey(userId int, name, role, keyHash string) (*storage.ApiKey, error) {
+	const op = "sqlite.CreateApiKey"
+
+	var key storage.ApiKey
+	err := s.db().QueryRow(`
+				INSERT INTO api_keys (user_id, name, role, key_hash) VALUES (?, ?, ?, ?)
+				RETURNING id, name, role, created_at
+			`, userId, name, role, keyHash).Scan(&key.Id, &key.Name, &key.Role, &key.CreatedAt)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (s *StoreSqlite) GetApiKeys(userId int) (*storage.ApiKeys, error) {
+	const op = "sqlite.GetApiKeys"
+
+	rows, err := s.db().Query(`SELECT id, name, role, created_at FROM api_keys WHERE user_id = ?`, userId)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := storage.ApiKeys{Keys: []storage.ApiKey{}}
+	for rows.Next() {
+		var key storage.ApiKey
+		if err := rows.Scan(&key.Id, &key.Name, &key.Role, &key.CreatedAt); err != nil {
+			s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+			return nil, err
+		}
+		keys.Keys = append(keys.Keys, key)
+	}
+	return &keys, nil
+}
+
+func (s *StoreSqlite) DeleteApiKey(userId int, id int) error {
+	const op = "sqlite.DeleteApiKey"
+
+	result, err := s.db().Exec(`DELETE FROM api_keys WHERE id = ? AND user_id = ?`, id, userId)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return ErrorSqliteNew(http.StatusNotFound, "api key not found")
+	}
+	return nil
+}
+
+func (s *StoreSqlite) GetUserByApiKey(keyHash string) (*storage.User, error) {
+	const op = "sqlite.GetUserByApiKey"
+
+	var id int
+	var name, keyRole, userRole, createdAt string
+	err := s.db().QueryRow(`
+				SELECT u.id, u.name, k.role, u.role, u.created_at
+				FROM api_keys k
+				JOIN users u ON u.id = k.user_id
+				WHERE k.key_hash = ?
+			`, keyHash).Scan(&id, &name, &keyRole, &userRole, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrorSqliteNew(http.StatusUnauthorized, "api key not found")
+	}
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	// user could be demoted after key was created
+	return storage.NewUser(id, name, storage.WeakerRole(keyRole, userRole), createdAt), nil
+}
+
+func (s *StoreSqlite) SetCalendarToken(userId int, tokenHash string) error {
+	const op = "sqlite.SetCalendarToken"
+
+	_, err := s.db().Exec(`
+				INSERT INTO calendar_tokens (user_id, token_hash) VALUES (?, ?)
+				ON CONFLICT (user_id) DO UPDATE SET token_hash = excluded.token_hash, created_at = CURRENT_TIMESTAMP
+			`, userId, tokenHash)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	return nil
+}
+
+func (s *StoreSqlite) DeleteCalendarToken(userId int) error {
+	const op = "sqlite.DeleteCalendarToken"
+
+	result, err := s.db().Exec(`DELETE FROM calendar_tokens WHERE user_id = ?`, userId)
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return err
+	}
+	if count, _ := result.RowsAffected(); count == 0 {
+		return ErrorSqliteNew(http.StatusNotFound, "calendar token not found")
+	}
+	return nil
+}
+
+func (s *StoreSqlite) GetUserByCalendarToken(tokenHash string) (*storage.User, error) {
+	const op = "sqlite.GetUserByCalendarToken"
+
+	var id int
+	var name, role, createdAt string
+	err := s.db().QueryRow(`
+				SELECT u.id, u.name, u.role, u.created_at
+				FROM calendar_tokens c
+				JOIN users u ON u.id = c.user_id
+				WHERE c.token_hash = ?
+			`, tokenHash).Scan(&id, &name, &role, &createdAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrorSqliteNew(http.StatusUnauthorized, "calendar token not found")
+	}
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return nil, err
+	}
+	return storage.NewUser(id, name, role, createdAt), nil
+}
+
+// userIdByName returns id of the user with name, 404 error if user doesn't exist
+func (s *StoreSqlite) userIdByName(name string) (int, error) {
+	const op = "sqlite.userIdByName"
+
+	var id int
+	err := s.db().QueryRow(`SELECT id FROM users WHERE name = ?`, name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, ErrorSqliteNew(http.StatusNotFound, fmt.Sprintf("user '%s' not found", name))
+	}
+	if err != nil {
+		s.Log.Error(fmt.Sprintf("%s: %s", op, err.Error()))
+		return 0, err
+	}
+	return id, nil
+}
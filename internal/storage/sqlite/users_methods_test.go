@@ -0,0 +1,159 @@
+package sqlite
+
+import (
+	"net/http"
+	"testing"
+	"time"
+	"web/internal/auth"
+	"web/internal/storage"
+)
+
+func TestGetUserBySession(t *testing.T) {
+	tests := []struct {
+		name string
+		// expiresIn session lifetime left, negative - expired session
+		expiresIn time.Duration
+		// lookup returns token hash to look up by token of the session
+		lookup   func(token string) string
+		wantCode int
+	}{
+		{name: "valid session", expiresIn: time.Hour, lookup: auth.HashToken, wantCode: 0},
+		{name: "expired session", expiresIn: -time.Minute, lookup: auth.HashToken, wantCode: http.StatusUnauthorized},
+		{name: "token instead of hash", expiresIn: time.Hour, lookup: func(token string) string { return token }, wantCode: http.StatusUnauthorized},
+		{name: "unknown token", expiresIn: time.Hour, lookup: func(string) string { return auth.HashToken("unknown") }, wantCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			userId := newTestUser(t, s, "alice")
+			token, err := auth.NewToken()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = s.CreateSession(userId, auth.HashToken(token), time.Now().Add(tt.expiresIn)); err != nil {
+				t.Fatal(err)
+			}
+
+			user, err := s.GetUserBySession(tt.lookup(token))
+			if got := errorCode(err); got != tt.wantCode {
+				t.Fatalf("GetUserBySession() error = %v, want status %d", err, tt.wantCode)
+			}
+			if err == nil && user.Id != userId {
+				t.Errorf("user id = %d, want %d", user.Id, userId)
+			}
+		})
+	}
+}
+
+func TestSessionIsStoredAsHash(t *testing.T) {
+	s := newTestStore(t)
+	userId := newTestUser(t, s, "alice")
+	token, err := auth.NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = s.CreateSession(userId, auth.HashToken(token), time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	var leaked bool
+	err = s.DataBase.QueryRow(`SELECT EXISTS (SELECT 1 FROM sessions WHERE token_hash = ?)`, token).Scan(&leaked)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaked {
+		t.Error("session token is stored as is")
+	}
+}
+
+func TestDeleteSession(t *testing.T) {
+	s := newTestStore(t)
+	userId := newTestUser(t, s, "alice")
+	hash := auth.HashToken("token")
+	if err := s.CreateSession(userId, hash, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.DeleteSession(hash); err != nil {
+		t.Fatalf("DeleteSession() error = %v", err)
+	}
+	if _, err := s.GetUserBySession(hash); errorCode(err) != http.StatusUnauthorized {
+		t.Errorf("GetUserBySession() after delete error = %v, want status %d", err, http.StatusUnauthorized)
+	}
+	if err := s.DeleteSession(hash); errorCode(err) != http.StatusNotFound {
+		t.Errorf("second DeleteSession() error = %v, want status %d", err, http.StatusNotFound)
+	}
+}
+
+func TestGetUserByApiKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		keyRole  string
+		userRole string
+		wantRole string
+	}{
+		{name: "key of same role", keyRole: storage.RoleMember, userRole: storage.RoleMember, wantRole: storage.RoleMember},
+		{name: "key weaker than user", keyRole: storage.RoleReadOnly, userRole: storage.RoleAdmin, wantRole: storage.RoleReadOnly},
+		{name: "user demoted after key is created", keyRole: storage.RoleAdmin, userRole: storage.RoleMember, wantRole: storage.RoleMember},
+		{name: "user demoted to read-only", keyRole: storage.RoleMember, userRole: storage.RoleReadOnly, wantRole: storage.RoleReadOnly},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			// first user is admin, so role of the second user can be changed
+			newTestUser(t, s, "admin")
+			userId := newTestUser(t, s, "alice")
+			if _, err := s.CreateApiKey(userId, "key", tt.keyRole, auth.HashToken("key")); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.SetUserRole("alice", tt.userRole); err != nil {
+				t.Fatal(err)
+			}
+
+			user, err := s.GetUserByApiKey(auth.HashToken("key"))
+			if err != nil {
+				t.Fatalf("GetUserByApiKey() error = %v", err)
+			}
+			if user.Id != userId || user.Role != tt.wantRole {
+				t.Errorf("user = %d with role %q, want %d with role %q", user.Id, user.Role, userId, tt.wantRole)
+			}
+		})
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	tests := []struct {
+		name string
+		// admins names of users with admin role, first user is always admin
+		admins   []string
+		user     string
+		role     string
+		wantCode int
+	}{
+		{name: "only admin loses admin role", admins: []string{"alice"}, user: "alice", role: storage.RoleMember, wantCode: http.StatusConflict},
+		{name: "only admin keeps admin role", admins: []string{"alice"}, user: "alice", role: storage.RoleAdmin, wantCode: 0},
+		{name: "one of admins loses admin role", admins: []string{"alice", "bob"}, user: "alice", role: storage.RoleReadOnly, wantCode: 0},
+		{name: "member becomes admin", admins: []string{"alice"}, user: "bob", role: storage.RoleAdmin, wantCode: 0},
+		{name: "unknown user", admins: []string{"alice"}, user: "nobody", role: storage.RoleMember, wantCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestStore(t)
+			newTestUser(t, s, "alice")
+			newTestUser(t, s, "bob")
+			for _, name := range tt.admins[1:] {
+				if _, err := s.SetUserRole(name, storage.RoleAdmin); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			user, err := s.SetUserRole(tt.user, tt.role)
+			if got := errorCode(err); got != tt.wantCode {
+				t.Fatalf("SetUserRole() error = %v, want status %d", err, tt.wantCode)
+			}
+			if err == nil && user.Role != tt.role {
+				t.Errorf("role = %q, want %q", user.Role, tt.role)
+			}
+		})
+	}
+}
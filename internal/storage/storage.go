@@ -1,63 +1,253 @@
 package storage
 
 import (
+	"io"
 	"log/slog"
 	"time"
 	"web/internal/config"
 )
 
-// Storage - database interface
+// Storage - database interface.
+// All task and tag methods are scoped to the caller, userId - id of the caller.
+// Caller sees own tasks, tasks assigned to him and tasks shared with him directly or by project.
+// Tags belong to the project, projectId = 0 - personal tags of the user.
+// Changes of tasks, their parts, tags and projects are recorded to audit log in the same transaction.
 type Storage interface {
 	// Connect connect to database.
 	// cfg *config.Config - configuration of database connection.
 	// log *slog.Logger - logger.
 	Connect(cfg *config.Config, log *slog.Logger) Storage
 
-	// CreateTask creates new task with selected parameters.
-	CreateTask(text string, tags []string, dueDate *time.Time) error
+	// Close closes connection to database, storage can't be used after Close.
+	Close() error
+
+	// CreateTask creates new task with selected parameters, new task is the last in user defined order.
+	// Task of the project belongs to the owner of the project.
+	CreateTask(userId int, task *TaskCreate) (*Task, error)
+
+	// UpdateTask changes not nil fields of the task, caller must have write access to the task.
+	// Parent task must be in the same project, parent which makes cycle is rejected.
+	UpdateTask(userId int, id int, update *TaskUpdate) (*Task, error)
+
+	// MoveTask places task right before or after anchor task in user defined order of tasks.
+	// Order is shared by all views, so task is next to anchor in any project or tag view containing both.
+	MoveTask(userId int, id int, anchorId int, after bool) (*Task, error)
+
+	// GetTaskTree returns task with its checklist and visible subtasks of any depth.
+	GetTaskTree(userId int, id int) (*TaskTree, error)
+
+	// AddDependency makes blocker task block blocked task, caller must have write access to blocked task.
+	// Dependency which makes cycle is rejected.
+	AddDependency(userId int, blockerId int, blockedId int) error
+
+	// RemoveDependency removes dependency between tasks.
+	RemoveDependency(userId int, blockerId int, blockedId int) error
+
+	// GetDependencyGraph returns visible tasks with dependencies matching filter and dependencies between them.
+	GetDependencyGraph(userId int, filter *TaskFilter) (*DependencyGraph, error)
+
+	// GetComments returns comments of the task from the oldest one.
+	GetComments(userId int, taskId int) (*Comments, error)
+
+	// CreateComment adds comment of the user to the task, caller must see the task.
+	CreateComment(userId int, taskId int, text string) (*Comment, error)
+
+	// DeleteComment deletes comment, only author of the comment and owner of the task can delete it.
+	DeleteComment(userId int, taskId int, id int) error
+
+	// GetAttachments returns attachments of the task from the oldest one.
+	GetAttachments(userId int, taskId int) (*Attachments, error)
+
+	// GetAttachment returns attachment of the task by ID.
+	GetAttachment(userId int, taskId int, id int) (*Attachment, error)
+
+	// CheckTaskWriteAccess returns 404 error if task is not visible to the user and 403 error if user has no write access to it.
+	CheckTaskWriteAccess(userId int, taskId int) error
+
+	// CreateAttachment adds attachment with stored blob to the task, caller must have write access to the task.
+	CreateAttachment(userId int, taskId int, name, mimeType string, blob *Blob) (*Attachment, error)
+
+	// DeleteAttachment deletes attachment, only author of the attachment and owner of the task can delete it.
+	DeleteAttachment(userId int, taskId int, id int) error
+
+	// DeleteUnusedBlobs forgets blobs without attachments and returns their hashes to delete from BlobStore.
+	DeleteUnusedBlobs() ([]string, error)
+
+	// GetTaskHistory returns changes of the task and its parts from the oldest one.
+	// History of deleted task is visible to its last owner.
+	GetTaskHistory(userId int, taskId int) (*AuditLog, error)
+
+	// GetAuditLog returns the newest changes of all users matching filter, from the newest one.
+	GetAuditLog(filter *AuditFilter) (*AuditLog, error)
+
+	// GetChecklist returns checklist items of the task.
+	GetChecklist(userId int, taskId int) (*Checklist, error)
+
+	// CreateChecklistItem adds item to the checklist of the task.
+	CreateChecklistItem(userId int, taskId int, text string) (*ChecklistItem, error)
+
+	// UpdateChecklistItem changes not nil fields of the checklist item.
+	UpdateChecklistItem(userId int, taskId int, id int, text *string, done *bool) (*ChecklistItem, error)
+
+	// DeleteChecklistItem deletes item from the checklist of the task.
+	DeleteChecklistItem(userId int, taskId int, id int) error
 
 	// GetTask gets task by ID.
-	GetTask(id int) (*Task, error)
+	GetTask(userId int, id int) (*Task, error)
 
 	// GetAllTasksByTag returns all tasks by tag or tags list.
 	// tag []string - list of tags or single tag
 	// returns *storage.AllTasks - list of storage.Task
-	GetTasksByTagShort(tagList []string) (*Tasks, error)
+	GetTasksByTagShort(userId int, tagList []string, filter *TaskFilter) (*Tasks, error)
 
 	// GetTaskByTag returns tasks only with specified tag or tags list.
 	// tag []string - list of tags or single tag.
 	// returns *storage.AllTasks - list of storage.Task.
-	GetTasksByTagFull(tagList []string) (*Tasks, error)
+	GetTasksByTagFull(userId int, tagList []string, filter *TaskFilter) (*Tasks, error)
+
+	// Delete moves task by ID or all own tasks to trash, trashed tasks are excluded from all other task methods.
+	// Task by ID can be deleted by owner and users with write share, its subtasks are moved to its parent.
+	DeleteTask(userId int, id ...string) error
+
+	// GetTrash returns trashed tasks the user can restore and trashed tags of own and writable projects.
+	GetTrash(userId int) (*Trash, error)
+
+	// RestoreTask restores task from trash, task becomes top level task if its parent is not restored.
+	RestoreTask(userId int, id int) (*Task, error)
+
+	// RestoreTag restores tag from trash.
+	RestoreTag(userId int, id int) (*Tag, error)
+
+	// EmptyTrash deletes tasks and tags of the trash of the user forever with checklists, comments, attachments
+	// and dependencies of tasks. Blobs of deleted attachments stay stored until DeleteUnusedBlobs.
+	EmptyTrash(userId int) error
+
+	// PurgeTrash deletes tasks and tags trashed before time forever, returns number of deleted tasks and tags.
+	PurgeTrash(before time.Time) (int, error)
+
+	// Transaction runs fn with storage whose methods run in one transaction, transaction is rolled back if fn returns error.
+	// Each method runs in own savepoint, so failed method doesn't change anything and next methods can run.
+	Transaction(fn func(tx Storage) error) error
 
-	// Delete deletes task by ID or all tasks.
-	// TODO check admin rules
-	DeleteTask(id ...string) error
+	// Operation returns storage which records its changes as one undoable operation with the token.
+	Operation(token string) Storage
 
-	// GetTag returns tag by name.
-	GetTag(name string) (*Tag, error)
+	// Undo reverses changes of the operation by token, empty token - the newest not undone operation of the user.
+	// Operation can be undone by its author within window after it was made, only if its tasks and tags
+	// were not changed after it. Only changes of tasks and tags can be undone. Returns changes of the undone operation.
+	Undo(userId int, token string, window time.Duration) (*AuditLog, error)
 
-	// GetAllTags returns all tags.
-	GetAllTags() (*Tags, error)
+	// Backup writes consistent snapshot of all data without stopping the database,
+	// sqlite writes database file, other databases can write json snapshot. Blobs of attachments are not included.
+	Backup(w io.Writer) error
+
+	// Restore replaces all data with snapshot written by Backup. Snapshot of newer schema version is rejected
+	// with 409 error, snapshot of older schema version is migrated before it replaces data.
+	Restore(r io.Reader) error
+
+	// CheckTaskTags compares tags of each task with its links in task_tags, including trashed tasks.
+	// Returns tasks whose tags and links differ and links of missing tasks, empty list if data is consistent.
+	CheckTaskTags() ([]TagMismatch, error)
+
+	// GetTag returns tag by name from tags of the project.
+	GetTag(userId int, projectId int, name string) (*Tag, error)
+
+	// GetAllTags returns all tags of the project.
+	GetAllTags(userId int, projectId int) (*Tags, error)
 
 	// GetAllTasks returns all tasks.
-	GetAllTasks() (*Tasks, error)
+	GetAllTasks(userId int, filter *TaskFilter) (*Tasks, error)
 
 	// GetTasksByDueDate returns tasks by due date.
-	GetTasksByDueDate(due *time.Time) (*Tasks, error)
+	GetTasksByDueDate(userId int, due *time.Time, filter *TaskFilter) (*Tasks, error)
 
-	// CreateTag creates new tag
-	CreateTag(name string) error
+	// CreateTag creates new tag in tags of the project, trashed tag with same name is restored
+	CreateTag(userId int, projectId int, name string) error
 
-	// DeleteTag deletes tag
-	DeleteTag(name ...string) error
+	// DeleteTag moves tag of the project to trash
+	DeleteTag(userId int, projectId int, name ...string) error
 
 	// GetTasksByDueAndTag returns tasks by due date and tag
-	GetTasksByDueAndTagFull(tagList []string, due *time.Time) (*Tasks, error)
-	GetTasksByDueAndTagShort(tagList []string, due *time.Time) (*Tasks, error)
+	GetTasksByDueAndTagFull(userId int, tagList []string, due *time.Time, filter *TaskFilter) (*Tasks, error)
+	GetTasksByDueAndTagShort(userId int, tagList []string, due *time.Time, filter *TaskFilter) (*Tasks, error)
+
+	GetTasksByTag(userId int, tagList []string, filter *TaskFilter) (*Tasks, error)
+
+	GetTasksByTagAndDue(userId int, tagList []string, due *time.Time, filter *TaskFilter) (*Tasks, error)
+
+	// CreateProject creates new project.
+	CreateProject(userId int, name string) (*Project, error)
+
+	// GetProject returns project by ID.
+	GetProject(userId int, id int) (*Project, error)
+
+	// GetProjects returns own projects of the user and projects shared with him.
+	GetProjects(userId int) (*Projects, error)
+
+	// RenameProject changes name of the project.
+	RenameProject(userId int, id int, name string) (*Project, error)
+
+	// DeleteProject deletes project without tasks and tags, trashed ones too, and shares of the project.
+	// Project with tasks or tags is not deleted, error has 409 status.
+	DeleteProject(userId int, id int) error
+
+	// ShareTask grants permission on the task to the user with name, only owner of the task can share it.
+	ShareTask(userId int, taskId int, name, permission string) error
+
+	// UnshareTask revokes share of the task from the user with name.
+	UnshareTask(userId int, taskId int, name string) error
+
+	// GetTaskShares returns shares of the own task.
+	GetTaskShares(userId int, taskId int) (*Shares, error)
+
+	// ShareProject grants permission on the project and all its tasks to the user with name.
+	ShareProject(userId int, projectId int, name, permission string) error
+
+	// UnshareProject revokes share of the project from the user with name.
+	UnshareProject(userId int, projectId int, name string) error
+
+	// GetProjectShares returns shares of the own project.
+	GetProjectShares(userId int, projectId int) (*Shares, error)
+
+	// CreateUser creates new user with hashed password.
+	// First user gets admin role and tasks and tags created before users, other users are members.
+	CreateUser(name, passwordHash string) (*User, error)
+
+	// SetUserRole changes role of the user, the only admin can't get other role, error has 409 status then.
+	SetUserRole(name, role string) (*User, error)
+
+	// GetUserCredentials returns user by name and his password hash.
+	GetUserCredentials(name string) (*User, string, error)
+
+	// CreateSession stores session token hash of the user.
+	CreateSession(userId int, tokenHash string, expiresAt time.Time) error
+
+	// GetUserBySession returns owner of non-expired session.
+	GetUserBySession(tokenHash string) (*User, error)
+
+	// DeleteSession deletes session by token hash.
+	DeleteSession(tokenHash string) error
+
+	// CreateApiKey stores api key hash of the user.
+	CreateApiKey(userId int, name, role, keyHash string) (*ApiKey, error)
+
+	// GetApiKeys returns api keys of the user, without keys itself.
+	GetApiKeys(userId int) (*ApiKeys, error)
+
+	// DeleteApiKey deletes api key of the user by ID.
+	DeleteApiKey(userId int, id int) error
+
+	// GetUserByApiKey returns owner of api key, role of returned user is role of the key.
+	GetUserByApiKey(keyHash string) (*User, error)
+
+	// SetCalendarToken stores calendar feed token hash of the user, previous token of the user stops working.
+	SetCalendarToken(userId int, tokenHash string) error
 
-	GetTasksByTag(tagList []string) (*Tasks, error)
+	// DeleteCalendarToken deletes calendar feed token of the user.
+	DeleteCalendarToken(userId int) error
 
-	GetTasksByTagAndDue(tagList []string, due *time.Time) (*Tasks, error)
+	// GetUserByCalendarToken returns owner of calendar feed token.
+	GetUserByCalendarToken(tokenHash string) (*User, error)
 }
 
 type SqlError interface {
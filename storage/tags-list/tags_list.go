@@ -8,13 +8,15 @@ import (
 
 type TagsList map[string]bool
 
-func NewTagsMemoryList(dataBase *storage.Storage, log *slog.Logger) *TagsList {
+// NewTagsMemoryList loads tags registry of the project, each project has own tags
+func NewTagsMemoryList(dataBase *storage.Storage, userId int, projectId int, log *slog.Logger) *TagsList {
 	const op = "tags_list.NewTagsMemoryList"
 	var tagsList = TagsList{}
 
-	tags, err := (*dataBase).GetAllTags()
+	tags, err := (*dataBase).GetAllTags(userId, projectId)
 	if err != nil {
-		log.Error(fmt.Sprintf("%v: %v", op, err.Error()))
+		log.Debug(fmt.Sprintf("%v: %v", op, err.Error()))
+		return &tagsList
 	}
 	for _, tag := range tags.Tags {
 		tagsList[tag.Name] = true